@@ -1,39 +1,323 @@
 package content
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/skatekrak/scribe/loaders"
 	"github.com/skatekrak/scribe/model"
 	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/database"
+	"github.com/skatekrak/utils/helpers"
 	"github.com/skatekrak/utils/middlewares"
 )
 
+// Interval of the comments keeping the streams open
+const heartbeatInterval = 15 * time.Second
+
+// Contents sent at most when a stream resumes
+const resumeLimit = 500
+
 type Controller struct {
-	s *services.ContentService
+	s      *services.ContentService
+	stream *services.ContentStream
 }
 
 // Find contents
 // @Summary  Fetch contents
+// @Description  Paginated by page numbers by default, the response is then a Pagination of the same items. With pagination=cursor, or a cursor, it's the CursorPagination below: follow its next and prev cursors.
+// @Description  The contents are cards by default. With projection=full they're whole contents, and with fields only the given fields.
 // @Tags     contents
-// @Param    sourceTypes  query     []string  false  "filter contents by source types"  Enums(rss,vimeo,youtube)
-// @Param    sources      query     []int     false  "filter contents by source id"
-// @Param    page         query     int       false  "Fetch page"  minimum(1)
-// @Success  200          {object}  database.Pagination{Items=[]model.Content}
-// @Failure  500          {object}  api.JSONError
+// @Param    sourceTypes      query     []string  false  "filter contents by source types"  Enums(rss,vimeo,youtube)
+// @Param    sources          query     []int     false  "filter contents by source id"
+// @Param    excludeSources   query     []int     false  "exclude the contents of these sources"
+// @Param    types            query     []string  false  "filter contents by type"  Enums(video,article)
+// @Param    subTypes         query     []string  false  "filter contents by sub type"  Enums(regular,short,live,upcoming,premiere)
+// @Param    langs            query     []string  false  "filter contents by lang ISO code, the detected one or the source one"
+// @Param    skateOnly        query     bool      false  "only the contents of skate sources"
+// @Param    publishedAfter   query     string    false  "contents published at or after this time"  Format(date-time)
+// @Param    publishedBefore  query     string    false  "contents published before this time"  Format(date-time)
+// @Param    minRelevance     query     number    false  "filter contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param    collapse         query     bool      false  "only list the canonical content of each group of duplicates"
+// @Param    sort             query     string    false  "order of the contents, newest first by default. Only newest with cursor pagination"  Enums(newest,oldest,relevance)
+// @Param    page             query     int       false  "Fetch page"  minimum(1)
+// @Param    pagination       query     string    false  "pagination mode"  Enums(page,cursor)
+// @Param    cursor           query     string    false  "next or prev cursor of a previous response"
+// @Param    limit            query     int       false  "contents per page, with cursor pagination"  minimum(1)  maximum(100)
+// @Param    count            query     bool      false  "count the total of contents, with cursor pagination"
+// @Param    ids              query     []string  false  "fetch these contents instead, the response is a ContentBatch"
+// @Param    projection       query     string    false  "fields of the contents: card, the default, leaves out the details and gives a compact source"  Enums(card,full)
+// @Param    fields           query     []string  false  "fields of the contents, by their JSON name, instead of a projection"
+// @Success  200              {object}  database.CursorPagination{Items=[]model.ContentCard}
+// @Failure  400              {object}  api.JSONError
+// @Failure  500              {object}  api.JSONError
 // @Router   /contents [get]
 func (c *Controller) Find(ctx *fiber.Ctx) error {
 	query := ctx.Locals(middlewares.QUERY).(FindQuery)
 
-	pagination, err := c.s.Find(query.SourceTypes, query.Sources, query.Page)
+	projection, err := services.NewProjection(query.Projection, query.Fields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if len(query.IDs) > 0 {
+		return c.batch(ctx, query.IDs, projection)
+	}
+
+	if query.withCursor() {
+		if query.Sort != "" && query.Sort != services.SortNewest {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Cursor pagination only sorts the newest contents first",
+			})
+		}
+
+		pagination, err := c.s.FindWithCursor(query.filters(), query.Cursor, query.Limit, query.Count, projection)
+		if errors.Is(err, database.ErrInvalidCursor) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if pagination.Items, err = project(projection, pagination.Items.([]model.Content)); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusOK).JSON(pagination)
+	}
+
+	pagination, err := c.s.Find(query.filters(), query.Sort, query.Page, projection)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	if pagination.Items, err = project(projection, pagination.Items.([]model.Content)); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	return ctx.Status(fiber.StatusOK).JSON(pagination)
 }
 
+// Fetch contents by ID
+// @Summary  Fetch up to 100 contents by ID, in the order of the IDs, along with the IDs not found
+// @Tags     contents
+// @Param    body  body      content.BatchBody  true  "IDs of the contents"
+// @Success  200   {object}  content.BatchResult{Items=[]model.ContentCard}
+// @Failure  400   {object}  api.JSONError
+// @Failure  500   {object}  api.JSONError
+// @Router   /contents/batch [post]
+func (c *Controller) Batch(ctx *fiber.Ctx) error {
+	body := ctx.Locals(middlewares.BODY).(BatchBody)
+
+	projection, err := services.NewProjection(body.Projection, body.Fields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.batch(ctx, body.IDs, projection)
+}
+
+func (c *Controller) batch(ctx *fiber.Ctx, ids []string, projection services.Projection) error {
+	contents, missing, err := c.s.FindByIDs(ids, projection)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	items, err := project(projection, contents)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(BatchResult{
+		Items:   items,
+		Missing: missing,
+	})
+}
+
+// Search contents
+// @Summary  Search contents by their title, summary, body and source title, best match first
+// @Tags     contents
+// @Param    query            query     string    true   "searched words, quoted phrases, or and -excluded words are supported"
+// @Param    sourceTypes      query     []string  false  "filter contents by source types"  Enums(rss,vimeo,youtube)
+// @Param    sources          query     []int     false  "filter contents by source id"
+// @Param    excludeSources   query     []int     false  "exclude the contents of these sources"
+// @Param    types            query     []string  false  "filter contents by type"  Enums(video,article)
+// @Param    subTypes         query     []string  false  "filter contents by sub type"  Enums(regular,short,live,upcoming,premiere)
+// @Param    langs            query     []string  false  "filter contents by lang ISO code, the detected one or the source one"
+// @Param    skateOnly        query     bool      false  "only the contents of skate sources"
+// @Param    publishedAfter   query     string    false  "contents published at or after this time"  Format(date-time)
+// @Param    publishedBefore  query     string    false  "contents published before this time"  Format(date-time)
+// @Param    minRelevance     query     number    false  "filter contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param    collapse         query     bool      false  "only list the canonical content of each group of duplicates"
+// @Param    sort             query     string    false  "order of the contents, best match first by default"  Enums(newest,oldest,relevance)
+// @Param    page             query     int       false  "Fetch page"  minimum(1)
+// @Param    projection       query     string    false  "fields of the contents: card, the default, leaves out the details and gives a compact source"  Enums(card,full)
+// @Param    fields           query     []string  false  "fields of the contents, by their JSON name, instead of a projection"
+// @Success  200              {object}  database.Pagination{Items=[]services.SearchResult}
+// @Failure  400              {object}  api.JSONError
+// @Failure  500              {object}  api.JSONError
+// @Router   /contents/search [get]
+func (c *Controller) Search(ctx *fiber.Ctx) error {
+	query := ctx.Locals(middlewares.QUERY).(SearchQuery)
+
+	projection, err := services.NewProjection(query.Projection, query.Fields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	pagination, err := c.s.Search(query.Query, query.filters(), query.Sort, query.Page, projection)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	results := pagination.Items.([]services.SearchResult)
+	projected := make([]searchResult, len(results))
+	for i, result := range results {
+		content, err := projection.Project(result.Content)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		projected[i] = searchResult{result, content}
+	}
+	pagination.Items = projected
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination)
+}
+
+// Stream the new contents
+// @Summary      Stream the contents as they're ingested, as Server-Sent Events
+// @Description  Each content is sent as a "content" event. Reconnecting with the Last-Event-ID header sends the contents missed since this event first.
+// @Description  The contents ingested shortly before this event are sent again too, in case one was committed late: skip the content IDs already received. Comments are sent as heartbeats.
+// @Tags         contents
+// @Produce      text/event-stream
+// @Param        Last-Event-ID    header    string    false  "ID of the last received event"
+// @Param        sourceTypes      query     []string  false  "filter contents by source types"  Enums(rss,vimeo,youtube)
+// @Param        sources          query     []int     false  "filter contents by source id"
+// @Param        excludeSources   query     []int     false  "exclude the contents of these sources"
+// @Param        types            query     []string  false  "filter contents by type"  Enums(video,article)
+// @Param        subTypes         query     []string  false  "filter contents by sub type"  Enums(regular,short,live,upcoming,premiere)
+// @Param        langs            query     []string  false  "filter contents by lang ISO code, the detected one or the source one"
+// @Param        skateOnly        query     bool      false  "only the contents of skate sources"
+// @Param        minRelevance     query     number    false  "filter contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param        collapse         query     bool      false  "only the canonical content of each group of duplicates"
+// @Success      200              {object}  model.Content
+// @Failure      400              {object}  api.JSONError
+// @Router       /contents/stream [get]
+func (c *Controller) Stream(ctx *fiber.Ctx) error {
+	query := ctx.Locals(middlewares.QUERY).(FindQuery)
+	filters := query.filters()
+
+	var last *database.Cursor
+	if id := ctx.Get("Last-Event-ID"); id != "" {
+		cursor, err := database.DecodeCursor(id)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid Last-Event-ID",
+			})
+		}
+		last = &cursor
+	}
+
+	// Subscribed before resuming, so nothing is inserted in between unseen
+	subscription := c.stream.Subscribe(filters)
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer c.stream.Unsubscribe(subscription)
+
+		fmt.Fprintf(w, "retry: %d\n\n", 5000)
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		// Sent while resuming, the subscription may have them too
+		resumed := map[string]bool{}
+
+		if last != nil {
+			missed, err := c.s.FindCreatedAfter(filters, services.ResumeCursor(*last), resumeLimit)
+			if err != nil {
+				log.Printf("Couldn't resume the stream: %s", err)
+				return
+			}
+
+			for i := range missed {
+				if err := writeEvent(w, &missed[i]); err != nil {
+					return
+				}
+				resumed[missed[i].ID] = true
+			}
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case content, ok := <-subscription.Contents:
+				if !ok {
+					// Fell behind, the client resumes from its last event
+					return
+				}
+
+				if resumed[content.ID] {
+					continue
+				}
+				if err := writeEvent(w, &content); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, content *model.Content) error {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "id: %s\nevent: content\ndata: %s\n\n", services.StreamCursor(content).Encode(), data)
+	return w.Flush()
+}
+
 // Get one content by id
 // @Summary  Get one content by id
 // @Tags     contents
@@ -46,3 +330,115 @@ func (c *Controller) Get(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(content)
 }
+
+// Update a content
+// @Summary   Update a content, keeping the changes as revisions. Locked fields are kept by refreshes
+// @Security  ApiKeyAuth
+// @Tags      contents
+// @Success   200        {object}  model.Content
+// @Failure   404        {object}  api.JSONError
+// @Failure   500        {object}  api.JSONError
+// @Param     body       body      content.UpdateBody  true  "Update body"
+// @Param     contentId  path      string              true  "ID of the content"
+// @Router    /contents/{contentId} [patch]
+func (c *Controller) Update(ctx *fiber.Ctx) error {
+	body := ctx.Locals(middlewares.BODY).(UpdateBody)
+	content := ctx.Locals(loaders.CONTENT_LOADER_LOCAL).(model.Content)
+
+	updated := content
+	updated.Title = helpers.SetIfNotNil(body.Title, content.Title)
+	updated.PublishedAt = helpers.SetIfNotNil(body.PublishedAt, content.PublishedAt)
+	updated.Summary = helpers.SetIfNotNil(body.Summary, content.Summary)
+	updated.RawSummary = helpers.SetIfNotNil(body.RawSummary, content.RawSummary)
+	updated.ThumbnailURL = helpers.SetIfNotNil(body.ThumbnailURL, content.ThumbnailURL)
+
+	if err := c.s.Update(&content, &updated, model.RevisionCauseManual); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Couldn't update the content",
+			"error":   err.Error(),
+		})
+	}
+
+	if body.LockedFields != nil {
+		if err := c.s.SetLockedFields(&updated, *body.LockedFields); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Couldn't lock the content fields",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(updated)
+}
+
+// Fetch the duplicates of a content
+// @Summary  Fetch the other contents of the group of duplicates of a content, canonical one included
+// @Tags     contents
+// @Success  200        {array}   []model.Content
+// @Failure  404        {object}  api.JSONError
+// @Failure  500        {object}  api.JSONError
+// @Param    contentId  path      string  true  "ID of the content"
+// @Router   /contents/{contentId}/duplicates [get]
+func (c *Controller) FindDuplicates(ctx *fiber.Ctx) error {
+	content := ctx.Locals(loaders.CONTENT_LOADER_LOCAL).(model.Content)
+
+	duplicates, err := c.s.FindDuplicates(&content)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(duplicates)
+}
+
+// Fetch the revisions of a content
+// @Summary   Fetch the history of a content, latest revision first
+// @Security  ApiKeyAuth
+// @Tags      contents
+// @Success   200        {array}   []model.ContentRevision
+// @Failure   404        {object}  api.JSONError
+// @Failure   500        {object}  api.JSONError
+// @Param     contentId  path      string  true  "ID of the content"
+// @Router    /contents/{contentId}/revisions [get]
+func (c *Controller) FindRevisions(ctx *fiber.Ctx) error {
+	content := ctx.Locals(loaders.CONTENT_LOADER_LOCAL).(model.Content)
+
+	revisions, err := c.s.FindRevisions(content.ID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(revisions)
+}
+
+// Revert a content to a revision
+// @Summary   Restore a content as it was right after the given revision
+// @Security  ApiKeyAuth
+// @Tags      contents
+// @Success   200         {object}  model.Content
+// @Failure   404         {object}  api.JSONError
+// @Failure   500         {object}  api.JSONError
+// @Param     contentId   path      string   true  "ID of the content"
+// @Param     revisionId  path      integer  true  "ID of the revision"
+// @Router    /contents/{contentId}/revisions/{revisionId}/revert [post]
+func (c *Controller) Revert(ctx *fiber.Ctx) error {
+	content := ctx.Locals(loaders.CONTENT_LOADER_LOCAL).(model.Content)
+
+	revision, err := c.s.GetRevision(content.ID, ctx.Params("revisionId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Revision not found")
+	}
+
+	reverted, err := c.s.Revert(&content, revision)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Couldn't revert the content",
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(reverted)
+}
@@ -1,29 +1,144 @@
 package content
 
 import (
+	"os"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/model"
 	"github.com/skatekrak/scribe/services"
 	"github.com/skatekrak/utils/middlewares"
 	"gorm.io/gorm"
 )
 
 type FindQuery struct {
-	SourceTypes []string `json:"sourceTypes" validate:"dive,eq=vimeo|eq=youtube|eq=rss"`
-	Sources     []int    `json:"sources"`
-	Page        int      `json:"page"`
+	SourceTypes     []string `json:"sourceTypes" validate:"dive,eq=vimeo|eq=youtube|eq=rss"`
+	Sources         []int    `json:"sources"`
+	ExcludeSources  []int    `json:"excludeSources"`
+	Types           []string `json:"types" validate:"dive,eq=video|eq=article"`
+	SubTypes        []string `json:"subTypes" validate:"dive,eq=regular|eq=short|eq=live|eq=upcoming|eq=premiere"`
+	Langs           []string `json:"langs" validate:"dive,len=2"`
+	SkateOnly       bool     `json:"skateOnly"`
+	PublishedAfter  string   `json:"publishedAfter" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	PublishedBefore string   `json:"publishedBefore" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	MinRelevance    *float64 `json:"minRelevance" validate:"omitempty,min=0,max=1"`
+	Collapse        bool     `json:"collapse"`
+	Sort            string   `json:"sort" validate:"omitempty,oneof=newest oldest relevance"`
+	Page            int      `json:"page"`
+
+	// Batch fetch instead, the other parameters are ignored
+	IDs []string `json:"ids" validate:"max=100"`
+
+	// Content fields of the list, a card by default
+	Projection string   `json:"projection" validate:"omitempty,oneof=card full"`
+	Fields     []string `json:"fields"`
+
+	// Cursor pagination, used instead of the pages when a cursor is given or when asked for
+	Pagination string `json:"pagination" validate:"omitempty,oneof=page cursor"`
+	Cursor     string `json:"cursor"`
+	Limit      int    `json:"limit" validate:"omitempty,min=1,max=100"`
+	Count      bool   `json:"count"`
+}
+
+func (q FindQuery) withCursor() bool {
+	return q.Pagination == "cursor" || q.Cursor != ""
+}
+
+func (q FindQuery) filters() services.ContentFilters {
+	return services.ContentFilters{
+		SourceTypes:     q.SourceTypes,
+		Sources:         q.Sources,
+		ExcludeSources:  q.ExcludeSources,
+		Types:           q.Types,
+		SubTypes:        q.SubTypes,
+		Langs:           q.Langs,
+		SkateOnly:       q.SkateOnly,
+		PublishedAfter:  parseTime(q.PublishedAfter),
+		PublishedBefore: parseTime(q.PublishedBefore),
+		MinRelevance:    q.MinRelevance,
+		Collapse:        q.Collapse,
+	}
+}
+
+// Times of the query have been validated already
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
 }
 
-func Route(app *fiber.App, db *gorm.DB) {
+type SearchQuery struct {
+	FindQuery
+	Query string `json:"query" validate:"required,max=200"`
+}
+
+type BatchBody struct {
+	IDs        []string `json:"ids" validate:"required,min=1,max=100"`
+	Projection string   `json:"projection" validate:"omitempty,oneof=card full"`
+	Fields     []string `json:"fields"`
+}
+
+type BatchResult struct {
+	Items   []interface{} `json:"items"`   // In the order of the IDs
+	Missing []string      `json:"missing"` // IDs without content
+} // @name ContentBatch
+
+// Search result with the fields of the projection only
+type searchResult struct {
+	services.SearchResult
+	Content interface{} `json:"content"`
+}
+
+// Contents with the fields of the projection only
+func project(projection services.Projection, contents []model.Content) ([]interface{}, error) {
+	projected := make([]interface{}, len(contents))
+	for i, content := range contents {
+		item, err := projection.Project(content)
+		if err != nil {
+			return nil, err
+		}
+		projected[i] = item
+	}
+	return projected, nil
+}
+
+type UpdateBody struct {
+	Title        *string    `json:"title"`
+	PublishedAt  *time.Time `json:"publishedAt"`
+	Summary      *string    `json:"summary"`
+	RawSummary   *string    `json:"rawSummary"`
+	ThumbnailURL *string    `json:"thumbnailUrl"`
+	LockedFields *[]string  `json:"lockedFields" validate:"omitempty,dive,oneof=title publishedAt summary rawSummary thumbnailUrl"`
+}
+
+func Route(app *fiber.App, db *gorm.DB, stream *services.ContentStream) {
+	apiKey := os.Getenv("API_KEY")
+
 	contentService := services.NewContentService(db)
 	controller := &Controller{
-		s: contentService,
+		s:      contentService,
+		stream: stream,
 	}
 
 	router := app.Group("contents")
 
+	auth := middlewares.Authorization(apiKey)
 	contentLoader := loaders.ContentLoader(contentService)
 
 	router.Get("", middlewares.QueryHandler[FindQuery](), controller.Find)
+	router.Get("/stream", middlewares.QueryHandler[FindQuery](), controller.Stream)
+	router.Get("/search", middlewares.QueryHandler[SearchQuery](), controller.Search)
+	router.Post("/batch", middlewares.JSONHandler[BatchBody](), controller.Batch)
 	router.Get("/:contentId", contentLoader, controller.Get)
+	router.Patch("/:contentId", auth, contentLoader, middlewares.JSONHandler[UpdateBody](), controller.Update)
+	router.Get("/:contentId/duplicates", contentLoader, controller.FindDuplicates)
+	router.Get("/:contentId/revisions", auth, contentLoader, controller.FindRevisions)
+	router.Post("/:contentId/revisions/:revisionId/revert", auth, contentLoader, controller.Revert)
 }
@@ -0,0 +1,220 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/internal/httpcache"
+	"github.com/skatekrak/scribe/internal/syndication"
+	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+)
+
+// Number of contents in a feed
+const feedSize = 50
+
+// Mirrored thumbnails used for the enclosures, biggest first
+var enclosureDerivatives = []string{"large.jpg", "medium.jpg", "small.jpg"}
+
+type Controller struct {
+	s         *services.ContentService
+	publicURL string
+}
+
+// Feed of all the contents
+// @Summary   Feed of the latest contents, as RSS, Atom or JSON Feed
+// @Tags      feeds
+// @Produce   xml,json
+// @Success   200           {string}  string
+// @Success   304           {string}  string
+// @Failure   404           {object}  api.JSONError
+// @Failure   500           {object}  api.JSONError
+// @Param     format        path      string  true   "format of the feed"  Enums(rss,atom,json)
+// @Param     skateOnly     query     bool    false  "only the contents of skate sources"
+// @Param     minRelevance  query     number  false  "contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param     collapse      query     bool    false  "only the canonical content of each group of duplicates"
+// @Router    /feeds/contents.{format} [get]
+func (c *Controller) All(ctx *fiber.Ctx) error {
+	return c.send(ctx, "Scribe", "Latest skateboarding videos and articles", services.ContentFilters{})
+}
+
+// Feed of the contents of a source
+// @Summary   Feed of the latest contents of a source, as RSS, Atom or JSON Feed
+// @Tags      feeds
+// @Produce   xml,json
+// @Success   200           {string}  string
+// @Success   304           {string}  string
+// @Failure   404           {object}  api.JSONError
+// @Failure   500           {object}  api.JSONError
+// @Param     sourceID      path      integer  true   "ID of the source"
+// @Param     format        path      string   true   "format of the feed"  Enums(rss,atom,json)
+// @Param     minRelevance  query     number   false  "contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param     collapse      query     bool     false  "only the canonical content of each group of duplicates"
+// @Router    /feeds/sources/{sourceID}/contents.{format} [get]
+func (c *Controller) BySource(ctx *fiber.Ctx) error {
+	source := loaders.GetSource(ctx)
+
+	return c.send(ctx, fmt.Sprintf("Scribe - %s", source.Title), source.Description, services.ContentFilters{
+		Sources: []int{int(source.ID)},
+	})
+}
+
+// Feed of the contents of a type
+// @Summary   Feed of the latest videos or articles, as RSS, Atom or JSON Feed
+// @Tags      feeds
+// @Produce   xml,json
+// @Success   200           {string}  string
+// @Success   304           {string}  string
+// @Failure   404           {object}  api.JSONError
+// @Failure   500           {object}  api.JSONError
+// @Param     type          path      string  true   "type of the contents"  Enums(video,article)
+// @Param     format        path      string  true   "format of the feed"  Enums(rss,atom,json)
+// @Param     skateOnly     query     bool    false  "only the contents of skate sources"
+// @Param     minRelevance  query     number  false  "contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param     collapse      query     bool    false  "only the canonical content of each group of duplicates"
+// @Router    /feeds/types/{type}/contents.{format} [get]
+func (c *Controller) ByType(ctx *fiber.Ctx) error {
+	contentType := ctx.Params("type")
+	if contentType != "video" && contentType != "article" {
+		return fiber.NewError(fiber.StatusNotFound, "Type not found")
+	}
+
+	return c.send(ctx, fmt.Sprintf("Scribe - %ss", contentType), fmt.Sprintf("Latest skateboarding %ss", contentType), services.ContentFilters{
+		Types: []string{contentType},
+	})
+}
+
+// Feed of the contents of a lang
+// @Summary   Feed of the latest contents in a lang, as RSS, Atom or JSON Feed
+// @Tags      feeds
+// @Produce   xml,json
+// @Success   200           {string}  string
+// @Success   304           {string}  string
+// @Failure   404           {object}  api.JSONError
+// @Failure   500           {object}  api.JSONError
+// @Param     isoCode       path      string  true   "ISO code of the lang"
+// @Param     format        path      string  true   "format of the feed"  Enums(rss,atom,json)
+// @Param     skateOnly     query     bool    false  "only the contents of skate sources"
+// @Param     minRelevance  query     number  false  "contents with a skate relevance at least this high"  minimum(0)  maximum(1)
+// @Param     collapse      query     bool    false  "only the canonical content of each group of duplicates"
+// @Router    /feeds/langs/{isoCode}/contents.{format} [get]
+func (c *Controller) ByLang(ctx *fiber.Ctx) error {
+	lang := loaders.GetLang(ctx)
+
+	return c.send(ctx, fmt.Sprintf("Scribe - %s", lang.IsoCode), fmt.Sprintf("Latest skateboarding videos and articles in %s", lang.IsoCode), services.ContentFilters{
+		Langs: []string{lang.IsoCode},
+	})
+}
+
+// Write the feed of the contents matching the filters, or 304 when the client has it already
+func (c *Controller) send(ctx *fiber.Ctx, title string, description string, filters services.ContentFilters) error {
+	format := ctx.Params("format")
+	contentType, ok := syndication.ContentTypes[format]
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "Feed format not found")
+	}
+
+	query := ctx.Locals(middlewares.QUERY).(Query)
+	filters.SkateOnly = query.SkateOnly
+	filters.MinRelevance = query.MinRelevance
+	filters.Collapse = query.Collapse
+
+	contents, err := c.s.FindLatest(filters, feedSize)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	feed := syndication.Feed{
+		Title:       title,
+		Description: description,
+		Link:        c.publicURL,
+		FeedURL:     c.publicURL + ctx.OriginalURL(),
+		Items:       make([]syndication.Item, len(contents)),
+	}
+
+	for i, content := range contents {
+		feed.Items[i] = c.item(content)
+		if content.PublishedAt.After(feed.Updated) {
+			feed.Updated = content.PublishedAt
+		}
+	}
+
+	// The feed only changes with its contents, its hash is a stable ETag
+	data, err := feed.Encode(format)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	hash := sha256.Sum256(data)
+
+	etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(hash[:16]))
+
+	ctx.Set(fiber.HeaderETag, etag)
+	if !feed.Updated.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, feed.Updated.UTC().Format(http.TimeFormat))
+	}
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=900")
+
+	if httpcache.NotModified(ctx, etag, feed.Updated) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Status(fiber.StatusOK).Send(data)
+}
+
+func (c *Controller) item(content model.Content) syndication.Item {
+	author := content.Source.Title
+	if content.Author != nil && *content.Author != "" {
+		author = *content.Author
+	}
+
+	return syndication.Item{
+		ID:        "urn:uuid:" + content.ID,
+		Title:     content.Title,
+		Link:      content.ContentURL,
+		Summary:   content.Summary,
+		Author:    author,
+		Published: content.PublishedAt,
+		Image:     c.thumbnail(content),
+	}
+}
+
+// The mirrored thumbnail of the content, or the original one
+func (c *Controller) thumbnail(content model.Content) *syndication.Enclosure {
+	if image := content.ThumbnailImage; image != nil && c.publicURL != "" {
+		for _, derivative := range enclosureDerivatives {
+			if image.Derivatives.Has(derivative) {
+				return &syndication.Enclosure{
+					URL:  fmt.Sprintf("%s/images/%s/%s", c.publicURL, image.ID, derivative),
+					Type: "image/jpeg",
+				}
+			}
+		}
+	}
+
+	if content.ThumbnailURL == "" {
+		return nil
+	}
+
+	contentType := "image/jpeg"
+	if u, err := neturl.Parse(content.ThumbnailURL); err == nil {
+		if t := mime.TypeByExtension(path.Ext(u.Path)); strings.HasPrefix(t, "image/") {
+			contentType = t
+		}
+	}
+
+	return &syndication.Enclosure{URL: content.ThumbnailURL, Type: contentType}
+}
@@ -0,0 +1,35 @@
+package feed
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+	"gorm.io/gorm"
+)
+
+type Query struct {
+	SkateOnly    bool     `json:"skateOnly"`
+	MinRelevance *float64 `json:"minRelevance" validate:"omitempty,min=0,max=1"`
+	Collapse     bool     `json:"collapse"`
+}
+
+func Route(app *fiber.App, db *gorm.DB) {
+	controller := &Controller{
+		s:         services.NewContentService(db),
+		publicURL: strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+	}
+
+	sourceLoader := loaders.SourceLoader(services.NewSourceService(db))
+	langLoader := loaders.LangLoader(services.NewLangService(db))
+	query := middlewares.QueryHandler[Query]()
+
+	router := app.Group("/feeds")
+	router.Get("/contents.:format", query, controller.All)
+	router.Get("/sources/:sourceID/contents.:format", query, sourceLoader, controller.BySource)
+	router.Get("/types/:type/contents.:format", query, controller.ByType)
+	router.Get("/langs/:isoCode/contents.:format", query, langLoader, controller.ByLang)
+}
@@ -0,0 +1,59 @@
+package image
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/internal/storage"
+	"github.com/skatekrak/scribe/services"
+)
+
+type Controller struct {
+	s *services.ImageService
+}
+
+// Get a mirrored image
+// @Summary  Get a mirrored image with its derivatives
+// @Tags     images
+// @Success  200      {object}  model.Image
+// @Failure  404      {object}  api.JSONError
+// @Param    imageId  path      string  true  "ID of the image"
+// @Router   /images/{imageId} [get]
+func (c *Controller) Get(ctx *fiber.Ctx) error {
+	image, err := c.s.Get(ctx.Params("imageId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Image not found")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(image)
+}
+
+// Serve a derivative of a mirrored image
+// @Summary  Serve a derivative of a mirrored image
+// @Description  The WebP derivatives are only made by the builds with cgo, the derivatives of an image are listed by GET /images/{imageId}.
+// @Tags     images
+// @Produce  image/jpeg,image/webp
+// @Success  200         {file}    binary
+// @Failure  404         {object}  api.JSONError
+// @Param    imageId     path      string  true  "ID of the image"
+// @Param    derivative  path      string  true  "Size and format"  Enums(small.jpg,small.webp,medium.jpg,medium.webp,large.jpg,large.webp)
+// @Router   /images/{imageId}/{derivative} [get]
+func (c *Controller) GetDerivative(ctx *fiber.Ctx) error {
+	image, err := c.s.Get(ctx.Params("imageId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Image not found")
+	}
+
+	data, contentType, err := c.s.GetDerivative(image, ctx.Params("derivative"))
+	if errors.Is(err, storage.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Derivative not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// The ID is the hash of the source URL, a derivative never changes
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Status(fiber.StatusOK).Send(data)
+}
@@ -0,0 +1,18 @@
+package image
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/internal/storage"
+	"github.com/skatekrak/scribe/services"
+	"gorm.io/gorm"
+)
+
+func Route(app *fiber.App, db *gorm.DB, storage storage.Storage) {
+	controller := &Controller{
+		s: services.NewImageService(db, storage),
+	}
+
+	router := app.Group("/images")
+	router.Get("/:imageId", controller.Get)
+	router.Get("/:imageId/:derivative", controller.GetDerivative)
+}
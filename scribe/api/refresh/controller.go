@@ -12,6 +12,7 @@ type Controller struct {
 	rs               *services.RefreshService
 	ss               *services.SourceService
 	cs               *services.ContentService
+	config           *services.ConfigService
 	fetcher          *fetchers.Fetcher
 	feedlyCategoryID string
 }
@@ -20,59 +21,103 @@ type Controller struct {
 // @Summary   Refresh sources by there types
 // @Security  ApiKeyAuth
 // @Tags      refresh
-// @Success   200    {array}   []model.Content
-// @Failure   500    {object}  api.JSONError
-// @Param     types  query     []string  true  "Type of sources to refresh"  Enums(rss,vimeo,youtube)
+// @Success   200     {object}  services.RefreshDiff
+// @Failure   500     {object}  api.JSONError
+// @Param     types   query     []string  true   "Type of sources to refresh"  Enums(rss,vimeo,youtube)
+// @Param     dryRun  query     bool      false  "Only return what would change, without saving"
 // @Router    /refresh [patch]
 func (c *Controller) RefreshByTypes(ctx *fiber.Ctx) error {
 	query := ctx.Locals(middlewares.QUERY).(RefreshQuery)
 
-	contents, err := c.rs.RefreshByTypes(query.Types)
+	diff, err := c.rs.RefreshByTypes(query.Types, query.DryRun)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(contents)
+	return ctx.Status(fiber.StatusOK).JSON(diff)
 }
 
 // Refresh a given sources
 // @Summary   Refresh a given source
 // @Security  ApiKeyAuth
 // @Tags      refresh
-// @Success   200       {array}   []model.Source
+// @Success   200       {object}  services.RefreshDiff
 // @Failure   500       {object}  api.JSONError
 // @Param     sourceID  path      string  true   "Source ID"
 // @Param     force     query     bool    false  "Will override content attributes"
+// @Param     dryRun    query     bool    false  "Only return what would change, without saving"
 // @Router    /refresh/{sourceID} [patch]
 func (c *Controller) RefreshSource(ctx *fiber.Ctx) error {
 	source := loaders.GetSource(ctx)
 
 	query := ctx.Locals(middlewares.QUERY).(RefreshSourceQuery)
 
-	contents, errs := c.rs.RefreshBySource(source, query.Force)
+	diff, errs := c.rs.RefreshBySource(source, query.Force, query.DryRun)
 	if errs != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(errs)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(contents)
+	return ctx.Status(fiber.StatusOK).JSON(diff)
 }
 
 // Refresh feedly sources
 // @Summary   Query sources used in feedly and add missing ones in Scribe
 // @Security  ApiKeyAuth
 // @Tags      refresh
-// @Success   200  {array}   []model.Source
-// @Failure   500  {object}  api.JSONError
+// @Success   200     {object}  services.RefreshDiff
+// @Failure   500     {object}  api.JSONError
+// @Param     dryRun  query     bool  false  "Only return the sources that would be added, without saving"
 // @Router    /refresh/sync-feedly [patch]
 func (c *Controller) RefreshFeedly(ctx *fiber.Ctx) error {
-	sources, err := c.rs.RefreshFeedlySource()
+	query := ctx.Locals(middlewares.QUERY).(RefreshFeedlyQuery)
+
+	diff, err := c.rs.RefreshFeedlySource(query.DryRun)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(sources)
+	return ctx.Status(fiber.StatusOK).JSON(diff)
+}
+
+// Get the ingest pipeline
+// @Summary   Get the processors the fetched contents go through before being saved
+// @Security  ApiKeyAuth
+// @Tags      refresh
+// @Success   200  {object}  services.PipelineConfig
+// @Failure   500  {object}  api.JSONError
+// @Router    /refresh/pipeline [get]
+func (c *Controller) GetPipeline(ctx *fiber.Ctx) error {
+	config, err := c.config.GetPipelineConfig()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(config)
+}
+
+// Replace the ingest pipeline
+// @Summary   Replace the processors the fetched contents go through, in order
+// @Security  ApiKeyAuth
+// @Tags      refresh
+// @Success   200   {object}  services.PipelineConfig
+// @Failure   400   {object}  api.JSONError
+// @Failure   500   {object}  api.JSONError
+// @Param     body  body      services.PipelineConfig  true  "Pipeline"
+// @Router    /refresh/pipeline [put]
+func (c *Controller) SetPipeline(ctx *fiber.Ctx) error {
+	config := ctx.Locals(middlewares.BODY).(services.PipelineConfig)
+
+	if err := services.ValidatePipelineConfig(config); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := c.config.SetPipelineConfig(config); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(config)
 }
@@ -15,11 +15,17 @@ import (
 )
 
 type RefreshQuery struct {
-	Types []string `query:"types" validate:"required,dive,eq=vimeo|eq=youtube|eq=rss"`
+	Types  []string `query:"types" validate:"required,dive,eq=vimeo|eq=youtube|eq=rss"`
+	DryRun bool     `query:"dryRun"`
 }
 
 type RefreshSourceQuery struct {
-	Force bool `query:"force"`
+	Force  bool `query:"force"`
+	DryRun bool `query:"dryRun"`
+}
+
+type RefreshFeedlyQuery struct {
+	DryRun bool `query:"dryRun"`
 }
 
 func Route(app *fiber.App, db *gorm.DB) {
@@ -39,6 +45,7 @@ func Route(app *fiber.App, db *gorm.DB) {
 		rs:               refreshService,
 		ss:               sourceService,
 		cs:               contentService,
+		config:           services.NewConfigService(db),
 		fetcher:          fetcher,
 		feedlyCategoryID: feedlyCategoryID,
 	}
@@ -48,6 +55,8 @@ func Route(app *fiber.App, db *gorm.DB) {
 	router := app.Group("refresh")
 
 	router.Post("", auth, middlewares.QueryHandler[RefreshQuery](), controller.RefreshByTypes)
-	router.Post("/sync-feedly-sources", auth, controller.RefreshFeedly)
+	router.Post("/sync-feedly-sources", auth, middlewares.QueryHandler[RefreshFeedlyQuery](), controller.RefreshFeedly)
+	router.Get("/pipeline", auth, controller.GetPipeline)
+	router.Put("/pipeline", auth, middlewares.JSONHandler[services.PipelineConfig](), controller.SetPipeline)
 	router.Post("/:sourceID", auth, middlewares.QueryHandler[RefreshSourceQuery](), sourceLoader, controller.RefreshSource)
 }
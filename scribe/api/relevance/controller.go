@@ -0,0 +1,46 @@
+package relevance
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/internal/relevance"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+)
+
+type Controller struct {
+	config *services.ConfigService
+}
+
+// Get the relevance dictionary
+// @Summary   Get the dictionary used to score the skate relevance of contents
+// @Security  ApiKeyAuth
+// @Tags      relevance
+// @Success   200  {object}  relevance.Dictionary
+// @Failure   500  {object}  api.JSONError
+// @Router    /relevance/dictionary [get]
+func (c *Controller) GetDictionary(ctx *fiber.Ctx) error {
+	dictionary, err := c.config.GetRelevanceDictionary()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(dictionary)
+}
+
+// Replace the relevance dictionary
+// @Summary   Replace the dictionary used to score the skate relevance of new contents
+// @Security  ApiKeyAuth
+// @Tags      relevance
+// @Success   200   {object}  relevance.Dictionary
+// @Failure   500   {object}  api.JSONError
+// @Param     body  body      relevance.Dictionary  true  "Dictionary"
+// @Router    /relevance/dictionary [put]
+func (c *Controller) SetDictionary(ctx *fiber.Ctx) error {
+	dictionary := ctx.Locals(middlewares.BODY).(relevance.Dictionary)
+
+	if err := c.config.SetRelevanceDictionary(dictionary); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(dictionary)
+}
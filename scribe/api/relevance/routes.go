@@ -0,0 +1,24 @@
+package relevance
+
+import (
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/internal/relevance"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+	"gorm.io/gorm"
+)
+
+func Route(app *fiber.App, db *gorm.DB) {
+	apiKey := os.Getenv("API_KEY")
+
+	controller := &Controller{
+		config: services.NewConfigService(db),
+	}
+	auth := middlewares.Authorization(apiKey)
+
+	router := app.Group("relevance")
+	router.Get("/dictionary", auth, controller.GetDictionary)
+	router.Put("/dictionary", auth, middlewares.JSONHandler[relevance.Dictionary](), controller.SetDictionary)
+}
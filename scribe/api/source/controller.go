@@ -29,10 +29,15 @@ type Controller struct {
 // @Success  200    {array}   []model.Source
 // @Failure  500    {object}  api.JSONError
 // @Param    types  query     []string  false  "Filter by source types"  Enums(rss,vimeo,youtube)
+// @Param    ids    query     []string  false  "Fetch these sources instead, the response is a SourceBatch"
 // @Router   /sources [get]
 func (c *Controller) FindAll(ctx *fiber.Ctx) error {
 	query := ctx.Locals(middlewares.QUERY).(FindAllQuery)
 
+	if len(query.IDs) > 0 {
+		return c.batch(ctx, query.IDs)
+	}
+
 	sources, err := c.s.FindAll(query.Types)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -43,6 +48,33 @@ func (c *Controller) FindAll(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(sources)
 }
 
+// Fetch sources by ID
+// @Summary  Fetch up to 100 sources by ID, in the order of the IDs, along with the IDs not found
+// @Tags     sources
+// @Success  200   {object}  source.BatchResult
+// @Failure  400   {object}  api.JSONError
+// @Failure  500   {object}  api.JSONError
+// @Param    body  body      source.BatchBody  true  "IDs of the sources"
+// @Router   /sources/batch [post]
+func (c *Controller) Batch(ctx *fiber.Ctx) error {
+	body := ctx.Locals(middlewares.BODY).(BatchBody)
+	return c.batch(ctx, body.IDs)
+}
+
+func (c *Controller) batch(ctx *fiber.Ctx, ids []string) error {
+	sources, missing, err := c.s.FindByIDs(ids)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(BatchResult{
+		Items:   sources,
+		Missing: missing,
+	})
+}
+
 // Add a new source
 // @Summary   Add a new source
 // @Tags      sources
@@ -126,6 +158,7 @@ func (c *Controller) Create(ctx *fiber.Ctx) error {
 // @Security  ApiKeyAuth
 // @Tags     sources
 // @Success   200       {object}  model.Source
+// @Failure   400       {object}  api.JSONError
 // @Failure   500       {object}  api.JSONError
 // @Param     body      body      source.UpdateBody  true  "Update body"
 // @Param     sourceID  path      integer            true  "ID of the source"
@@ -142,6 +175,15 @@ func (c *Controller) Update(ctx *fiber.Ctx) error {
 	source.IconURL = helpers.SetIfNotNil(body.IconURL, source.IconURL)
 	source.CoverURL = helpers.SetIfNotNil(body.CoverURL, source.CoverURL)
 	source.WebsiteURL = helpers.SetIfNotNil(body.WebsiteURL, source.WebsiteURL)
+	source.LockedFields = helpers.SetIfNotNil((*model.FieldList)(body.LockedFields), source.LockedFields)
+	source.LockedContentFields = helpers.SetIfNotNil((*model.FieldList)(body.LockedContentFields), source.LockedContentFields)
+	source.IngestRules = helpers.SetIfNotNil(body.IngestRules, source.IngestRules)
+
+	if err := services.ValidateIngestRules(source.IngestRules); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
 
 	if err := c.s.Update(&source); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -9,6 +9,7 @@ import (
 	"github.com/skatekrak/scribe/clients/youtube"
 	"github.com/skatekrak/scribe/fetchers"
 	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/model"
 	"github.com/skatekrak/scribe/services"
 	"github.com/skatekrak/utils/middlewares"
 	"gorm.io/gorm"
@@ -16,8 +17,18 @@ import (
 
 type FindAllQuery struct {
 	Types []string `query:"types" validate:"dive,eq=vimeo|eq=youtube|eq=rss"`
+	IDs   []string `query:"ids" validate:"max=100"` // Batch fetch instead, types is ignored
 }
 
+type BatchBody struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100"`
+}
+
+type BatchResult struct {
+	Items   []model.Source `json:"items"`   // In the order of the IDs
+	Missing []string       `json:"missing"` // IDs without source
+} // @name SourceBatch
+
 type CreateBody struct {
 	URL           string `json:"url" validated:"required"`
 	LangIsoCode   string `json:"lang" validate:"required"`
@@ -38,6 +49,11 @@ type UpdateBody struct {
 	IconURL       *string `json:"iconURL"`
 	CoverURL      *string `json:"coverURL"`
 	WebsiteURL    *string `json:"websiteURL"`
+
+	LockedFields        *[]string `json:"lockedFields" validate:"omitempty,dive,oneof=title shortTitle description iconUrl coverUrl websiteUrl"`
+	LockedContentFields *[]string `json:"lockedContentFields" validate:"omitempty,dive,oneof=title publishedAt summary rawSummary thumbnailUrl"`
+
+	IngestRules *model.IngestRules `json:"ingestRules"`
 }
 
 type UpdateOrderBody = map[int]int
@@ -68,6 +84,7 @@ func Route(app *fiber.App, db *gorm.DB) {
 
 	router.Get("", middlewares.QueryHandler[FindAllQuery](), controller.FindAll)
 	router.Post("", auth, middlewares.JSONHandler[CreateBody](), controller.Create)
+	router.Post("/batch", middlewares.JSONHandler[BatchBody](), controller.Batch)
 	router.Patch("/order", auth, middlewares.JSONHandler[UpdateOrderBody](), controller.UpdateOrder)
 	router.Patch("/:sourceID", auth, sourceLoader, middlewares.JSONHandler[UpdateBody](), controller.Update)
 	router.Delete("/:sourceID", auth, sourceLoader, controller.Delete)
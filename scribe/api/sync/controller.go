@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+)
+
+type Controller struct {
+	s *services.SyncService
+}
+
+// Changes of the langs, sources and contents since the last sync
+// @Description  Without token every lang and source is sent, along with the contents of the last 30 days.
+// @Description  Deleted resources are sent with their deletedAt set. When more is true, sync again right away with the new token.
+// @Tags     sync
+// @Success  200    {object}  services.SyncResult
+// @Failure  400    {object}  api.JSONError
+// @Failure  500    {object}  api.JSONError
+// @Param    since  query     string   false  "Token returned by the previous sync"
+// @Param    limit  query     integer  false  "Maximum number of changes of each resource, 100 by default"  minimum(1)  maximum(500)
+// @Router   /sync [get]
+func (c *Controller) Sync(ctx *fiber.Ctx) error {
+	query := ctx.Locals(middlewares.QUERY).(SyncQuery)
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	result, err := c.s.Changes(query.Since, limit)
+	if errors.Is(err, services.ErrInvalidSyncToken) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(result)
+}
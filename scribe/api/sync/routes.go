@@ -0,0 +1,21 @@
+package sync
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+	"gorm.io/gorm"
+)
+
+type SyncQuery struct {
+	Since string `query:"since"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+func Route(app *fiber.App, db *gorm.DB) {
+	controller := &Controller{
+		s: services.NewSyncService(db),
+	}
+
+	app.Get("/sync", middlewares.QueryHandler[SyncQuery](), controller.Sync)
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/helpers"
+	"github.com/skatekrak/utils/middlewares"
+)
+
+type Controller struct {
+	s *services.WebhookService
+}
+
+// Fetch all webhooks
+// @Tags      webhooks
+// @Security  ApiKeyAuth
+// @Success   200  {array}   []model.Webhook
+// @Failure   500  {object}  api.JSONError
+// @Router    /webhooks [get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	webhooks, err := c.s.FindAll()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(webhooks)
+}
+
+// Subscribe a URL to content and source events
+// @Description  Deliveries are POSTed with the X-Scribe-Event, X-Scribe-Delivery and X-Scribe-Timestamp headers.
+// @Description  X-Scribe-Signature is sha256= followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret.
+// @Description  Failed deliveries are retried with an exponential backoff.
+// @Description  The secret is only returned by this call, keep it to check the signatures.
+// @Tags      webhooks
+// @Security  ApiKeyAuth
+// @Success   200   {object}  webhook.CreatedWebhook
+// @Failure   400   {object}  api.JSONError
+// @Failure   500   {object}  api.JSONError
+// @Param     body  body      webhook.CreateBody  true  "Create body"
+// @Router    /webhooks [post]
+func (c *Controller) Create(ctx *fiber.Ctx) error {
+	body := ctx.Locals(middlewares.BODY).(CreateBody)
+
+	webhook := model.Webhook{
+		URL:     body.URL,
+		Secret:  body.Secret,
+		Events:  body.Events,
+		Filters: body.Filters,
+		Enabled: body.Enabled == nil || *body.Enabled,
+	}
+
+	if err := c.s.Create(&webhook); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(CreatedWebhook{webhook, webhook.Secret})
+}
+
+// Update a webhook
+// @Tags      webhooks
+// @Security  ApiKeyAuth
+// @Success   200        {object}  model.Webhook
+// @Failure   400        {object}  api.JSONError
+// @Failure   404        {object}  api.JSONError
+// @Failure   500        {object}  api.JSONError
+// @Param     body       body      webhook.UpdateBody  true  "Update body"
+// @Param     webhookId  path      integer             true  "ID of the webhook"
+// @Router    /webhooks/{webhookId} [patch]
+func (c *Controller) Update(ctx *fiber.Ctx) error {
+	webhook := loaders.GetWebhook(ctx)
+	body := ctx.Locals(middlewares.BODY).(UpdateBody)
+
+	webhook.URL = helpers.SetIfNotNil(body.URL, webhook.URL)
+	webhook.Secret = helpers.SetIfNotNil(body.Secret, webhook.Secret)
+	webhook.Filters = helpers.SetIfNotNil(body.Filters, webhook.Filters)
+	webhook.Enabled = helpers.SetIfNotNil(body.Enabled, webhook.Enabled)
+	if body.Events != nil {
+		webhook.Events = *body.Events
+	}
+
+	if err := c.s.Update(&webhook); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(webhook)
+}
+
+// Delete a webhook, its pending deliveries are dropped
+// @Tags      webhooks
+// @Security  ApiKeyAuth
+// @Success   200        {object}  api.JSONMessage
+// @Failure   404        {object}  api.JSONError
+// @Failure   500        {object}  api.JSONError
+// @Param     webhookId  path      integer  true  "ID of the webhook"
+// @Router    /webhooks/{webhookId} [delete]
+func (c *Controller) Delete(ctx *fiber.Ctx) error {
+	webhook := loaders.GetWebhook(ctx)
+
+	if err := c.s.Delete(&webhook); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
+		"message": "Webhook deleted",
+	})
+}
+
+// Delivery log of a webhook, latest first
+// @Tags      webhooks
+// @Security  ApiKeyAuth
+// @Success   200        {object}  database.Pagination{Items=[]model.WebhookDelivery}
+// @Failure   400        {object}  api.JSONError
+// @Failure   404        {object}  api.JSONError
+// @Failure   500        {object}  api.JSONError
+// @Param     webhookId  path      integer  true   "ID of the webhook"
+// @Param     status     query     string   false  "Filter by status"  Enums(pending,delivered,failed)
+// @Param     page       query     integer  false  "Page"
+// @Router    /webhooks/{webhookId}/deliveries [get]
+func (c *Controller) Deliveries(ctx *fiber.Ctx) error {
+	webhook := loaders.GetWebhook(ctx)
+	query := ctx.Locals(middlewares.QUERY).(DeliveriesQuery)
+
+	pagination, err := c.s.FindDeliveries(webhook.ID, query.Status, query.Page)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(pagination)
+}
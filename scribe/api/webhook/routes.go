@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skatekrak/scribe/loaders"
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/middlewares"
+	"gorm.io/gorm"
+)
+
+type CreateBody struct {
+	URL     string               `json:"url" validate:"required,url"`
+	Events  []string             `json:"events" validate:"required,min=1,dive,oneof=content.created source.created source.updated source.deleted"`
+	Filters model.WebhookFilters `json:"filters"`
+	Secret  string               `json:"secret" validate:"omitempty,min=16"` // Generated when empty
+	Enabled *bool                `json:"enabled"`                            // Defaults to true
+}
+
+type UpdateBody struct {
+	URL     *string               `json:"url" validate:"omitempty,url"`
+	Events  *[]string             `json:"events" validate:"omitempty,min=1,dive,oneof=content.created source.created source.updated source.deleted"`
+	Filters *model.WebhookFilters `json:"filters"`
+	Secret  *string               `json:"secret" validate:"omitempty,min=16"`
+	Enabled *bool                 `json:"enabled"`
+}
+
+// The secret is only given once, when the webhook is created
+type CreatedWebhook struct {
+	model.Webhook
+	Secret string `json:"secret"`
+} // @name CreatedWebhook
+
+type DeliveriesQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending delivered failed"`
+	Page   int    `query:"page"`
+}
+
+func Route(app *fiber.App, db *gorm.DB) {
+	apiKey := os.Getenv("API_KEY")
+
+	webhookService := services.NewWebhookService(db)
+	controller := &Controller{
+		s: webhookService,
+	}
+
+	auth := middlewares.Authorization(apiKey)
+	webhookLoader := loaders.WebhookLoader(webhookService)
+
+	router := app.Group("/webhooks", auth)
+	router.Get("", controller.FindAll)
+	router.Post("", middlewares.JSONHandler[CreateBody](), controller.Create)
+	router.Patch("/:webhookId", webhookLoader, middlewares.JSONHandler[UpdateBody](), controller.Update)
+	router.Delete("/:webhookId", webhookLoader, controller.Delete)
+	router.Get("/:webhookId/deliveries", webhookLoader, middlewares.QueryHandler[DeliveriesQuery](), controller.Deliveries)
+}
@@ -5,18 +5,45 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 )
 
+type VimeoVideoStats struct {
+	Plays *int64 `json:"plays"`
+}
+
+type VimeoVideoTag struct {
+	Name string `json:"name"`
+}
+
+type VimeoVideoLikes struct {
+	Total int64 `json:"total"`
+}
+
+type VimeoVideoConnections struct {
+	Likes VimeoVideoLikes `json:"likes"`
+}
+
+type VimeoVideoMetadata struct {
+	Connections VimeoVideoConnections `json:"connections"`
+}
+
 type VimeoVideoItem struct {
-	URI            string        `json:"uri"`
-	Name           string        `json:"name"`
-	Description    string        `json:"description"`
-	Type           string        `json:"type"`
-	Link           string        `json:"link"`
-	PlayerEmbedURL string        `json:"player_embed_url"`
-	ReleaseTime    time.Time     `json:"release_time"`
-	Pictures       VimeoPictures `json:"pictures"`
+	URI            string             `json:"uri"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Type           string             `json:"type"`
+	Link           string             `json:"link"`
+	PlayerEmbedURL string             `json:"player_embed_url"`
+	ReleaseTime    time.Time          `json:"release_time"`
+	Pictures       VimeoPictures      `json:"pictures"`
+	Duration       int                `json:"duration"` // In seconds
+	Width          int                `json:"width"`
+	Height         int                `json:"height"`
+	Stats          VimeoVideoStats    `json:"stats"`
+	Tags           []VimeoVideoTag    `json:"tags"`
+	Metadata       VimeoVideoMetadata `json:"metadata"`
 }
 
 type VimeoPaging struct {
@@ -34,8 +61,25 @@ type VimeoVideosResponse struct {
 	Data    []VimeoVideoItem `json:"data"`
 }
 
+const videoFields = "uri,name,description,type,link,player_embed_url,release_time,pictures,duration,width,height,stats,tags.name,metadata.connections.likes.total"
+
+// Max number of URIs accepted by /videos
+const MaxVideoIDs = 100
+
 func (v *VimeoClient) FetchVideos(userID string) (VimeoVideosResponse, error) {
-	url := fmt.Sprintf("https://api.vimeo.com/users/%s/videos?fields=uri,name,description,type,link,player_embed_url,release_time,pictures", userID)
+	return v.fetchVideos(fmt.Sprintf("https://api.vimeo.com/users/%s/videos?fields=%s", userID, videoFields))
+}
+
+func (v *VimeoClient) FetchVideosByIDs(videoIDs []string) (VimeoVideosResponse, error) {
+	uris := make([]string, len(videoIDs))
+	for i, id := range videoIDs {
+		uris[i] = fmt.Sprintf("/videos/%s", id)
+	}
+
+	return v.fetchVideos(fmt.Sprintf("https://api.vimeo.com/videos?uris=%s&fields=%s&per_page=%d", strings.Join(uris, ","), videoFields, MaxVideoIDs))
+}
+
+func (v *VimeoClient) fetchVideos(url string) (VimeoVideosResponse, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 
 	if err != nil {
@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type VideoDetailsSnippet struct {
+	Title                string   `json:"title"`
+	Description          string   `json:"description"`
+	Tags                 []string `json:"tags"`
+	LiveBroadcastContent string   `json:"liveBroadcastContent"`
+}
+
+type VideoContentDetails struct {
+	Duration   string `json:"duration"` // ISO 8601, like PT12M34S
+	Definition string `json:"definition"`
+}
+
+// Counts are sent as strings, and are missing when hidden by the channel
+type VideoStatistics struct {
+	ViewCount string `json:"viewCount"`
+	LikeCount string `json:"likeCount"`
+}
+
+// Only set for live streams and premieres
+type VideoLiveStreamingDetails struct {
+	ActualStartTime    *time.Time `json:"actualStartTime"`
+	ActualEndTime      *time.Time `json:"actualEndTime"`
+	ScheduledStartTime *time.Time `json:"scheduledStartTime"`
+}
+
+type VideoDetailsItem struct {
+	Kind           string              `json:"kind"`
+	Etag           string              `json:"etag"`
+	ID             string              `json:"id"`
+	Snippet        VideoDetailsSnippet `json:"snippet"`
+	ContentDetails VideoContentDetails `json:"contentDetails"`
+	Statistics     VideoStatistics     `json:"statistics"`
+
+	LiveStreamingDetails *VideoLiveStreamingDetails `json:"liveStreamingDetails"`
+}
+
+// Max number of IDs accepted by videos.list
+const MaxVideoIDs = 50
+
+func (y *YoutubeClient) FetchVideosDetails(videoIDs []string) (FetchResponse[VideoDetailsItem], error) {
+	url := fmt.Sprintf("https://www.googleapis.com/youtube/v3/videos?id=%s&part=snippet,contentDetails,statistics,liveStreamingDetails&key=%s&maxResults=%d", strings.Join(videoIDs, ","), y.apiKey, MaxVideoIDs)
+
+	response, err := http.Get(url) //#nosec G107 -- False positive
+	if err != nil {
+		return FetchResponse[VideoDetailsItem]{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return FetchResponse[VideoDetailsItem]{}, fmt.Errorf("error fetching videos details: %s", response.Status)
+	}
+
+	responseData, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return FetchResponse[VideoDetailsItem]{}, err
+	}
+
+	var data FetchResponse[VideoDetailsItem]
+	if err := json.Unmarshal(responseData, &data); err != nil {
+		return FetchResponse[VideoDetailsItem]{}, err
+	}
+
+	return data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"regexp"
+	"strconv"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -64,3 +65,28 @@ func GetBestThumbnail(thumbnails SnippetThumbnails) string {
 	}
 	return thumbnails.Default.URL
 }
+
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// Number of seconds of an ISO 8601 duration, like PT12M34S
+func ParseDuration(duration string) (int, error) {
+	matches := isoDuration.FindStringSubmatch(duration)
+	if matches == nil {
+		return 0, errors.New("invalid duration")
+	}
+
+	seconds := 0
+	for i, unit := range []int{24 * 3600, 3600, 60, 1} {
+		if matches[i+1] == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, err
+		}
+		seconds += n * unit
+	}
+
+	return seconds, nil
+}
@@ -0,0 +1,24 @@
+// One-off command detecting the lang of the stored contents
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/database"
+)
+
+func main() {
+	db, err := database.Open(os.Getenv("POSTGRESQL_ADDON_URI"))
+	if err != nil {
+		log.Fatalf("unable to open database: %s", err)
+	}
+
+	updated, err := services.NewContentService(db).DetectLangAll(500)
+	if err != nil {
+		log.Fatalf("unable to detect the lang of contents: %s", err)
+	}
+
+	log.Printf("Lang of %d contents detected", updated)
+}
@@ -0,0 +1,24 @@
+// One-off command fingerprinting the stored contents, so the new ones can be grouped with them
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/database"
+)
+
+func main() {
+	db, err := database.Open(os.Getenv("POSTGRESQL_ADDON_URI"))
+	if err != nil {
+		log.Fatalf("unable to open database: %s", err)
+	}
+
+	updated, err := services.NewContentService(db).FingerprintAll(500)
+	if err != nil {
+		log.Fatalf("unable to fingerprint contents: %s", err)
+	}
+
+	log.Printf("Fingerprint of %d contents updated", updated)
+}
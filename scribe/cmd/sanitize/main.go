@@ -0,0 +1,24 @@
+// One-off command sanitizing the raw summary and content of the stored contents
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/database"
+)
+
+func main() {
+	db, err := database.Open(os.Getenv("POSTGRESQL_ADDON_URI"))
+	if err != nil {
+		log.Fatalf("unable to open database: %s", err)
+	}
+
+	updated, err := services.NewContentService(db).SanitizeAll(500)
+	if err != nil {
+		log.Fatalf("unable to sanitize contents: %s", err)
+	}
+
+	log.Printf("%d contents sanitized", updated)
+}
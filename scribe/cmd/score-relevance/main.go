@@ -0,0 +1,29 @@
+// One-off command scoring again the relevance of the stored contents, after a dictionary change
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/skatekrak/scribe/services"
+	"github.com/skatekrak/utils/database"
+)
+
+func main() {
+	db, err := database.Open(os.Getenv("POSTGRESQL_ADDON_URI"))
+	if err != nil {
+		log.Fatalf("unable to open database: %s", err)
+	}
+
+	scorer, err := services.NewConfigService(db).RelevanceScorer()
+	if err != nil {
+		log.Fatalf("unable to load the relevance dictionary: %s", err)
+	}
+
+	updated, err := services.NewContentService(db).ScoreRelevanceAll(scorer, 500)
+	if err != nil {
+		log.Fatalf("unable to score contents: %s", err)
+	}
+
+	log.Printf("Relevance of %d contents updated", updated)
+}
@@ -27,6 +27,7 @@ const docTemplate = `{
     "paths": {
         "/contents": {
             "get": {
+                "description": "Paginated by page numbers by default, the response is then a Pagination of the same items. With pagination=cursor, or a cursor, it's the CursorPagination below: follow its next and prev cursors.\nThe contents are cards by default. With projection=full they're whole contents, and with fields only the given fields.",
                 "tags": [
                     "contents"
                 ],
@@ -55,12 +56,162 @@ const docTemplate = `{
                         "name": "sources",
                         "in": "query"
                     },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "exclude the contents of these sources",
+                        "name": "excludeSources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "video",
+                                "article"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by type",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "regular",
+                                "short",
+                                "live",
+                                "upcoming",
+                                "premiere"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by sub type",
+                        "name": "subTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "filter contents by lang ISO code, the detected one or the source one",
+                        "name": "langs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "contents published at or after this time",
+                        "name": "publishedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "contents published before this time",
+                        "name": "publishedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "filter contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only list the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "order of the contents, newest first by default. Only newest with cursor pagination",
+                        "name": "sort",
+                        "in": "query"
+                    },
                     {
                         "minimum": 1,
                         "type": "integer",
                         "description": "Fetch page",
                         "name": "page",
                         "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "page",
+                            "cursor"
+                        ],
+                        "type": "string",
+                        "description": "pagination mode",
+                        "name": "pagination",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next or prev cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "contents per page, with cursor pagination",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "count the total of contents, with cursor pagination",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "fetch these contents instead, the response is a ContentBatch",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "card",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "fields of the contents: card, the default, leaves out the details and gives a compact source",
+                        "name": "projection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "fields of the contents, by their JSON name, instead of a projection",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -69,7 +220,7 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/Pagination"
+                                    "$ref": "#/definitions/CursorPagination"
                                 },
                                 {
                                     "type": "object",
@@ -77,7 +228,7 @@ const docTemplate = `{
                                         "Items": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/Content"
+                                                "$ref": "#/definitions/ContentCard"
                                             }
                                         }
                                     }
@@ -85,30 +236,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/JSONError"
-                        }
-                    }
-                }
-            }
-        },
-        "/contents/{contentId}": {
-            "get": {
-                "tags": [
-                    "contents"
-                ],
-                "summary": "Get one content by id",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/Content"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
@@ -122,49 +251,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/langs": {
-            "get": {
-                "tags": [
-                    "langs"
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/Lang"
-                                }
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/JSONError"
-                        }
-                    }
-                }
-            },
+        "/contents/batch": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
                 "tags": [
-                    "langs"
+                    "contents"
                 ],
+                "summary": "Fetch up to 100 contents by ID, in the order of the IDs, along with the IDs not found",
                 "parameters": [
                     {
-                        "description": "Create body",
+                        "description": "IDs of the contents",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/lang.CreateBody"
+                            "$ref": "#/definitions/content.BatchBody"
                         }
                     }
                 ],
@@ -172,11 +272,26 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Lang"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ContentBatch"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/ContentCard"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
@@ -190,82 +305,186 @@ const docTemplate = `{
                 }
             }
         },
-        "/langs/{isoCode}": {
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
+        "/contents/search": {
+            "get": {
                 "tags": [
-                    "langs"
+                    "contents"
                 ],
+                "summary": "Search contents by their title, summary, body and source title, best match first",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Lang ISO Code",
-                        "name": "isoCode",
-                        "in": "path",
+                        "description": "searched words, quoted phrases, or and -excluded words are supported",
+                        "name": "query",
+                        "in": "query",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/JSONMessage"
-                        }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/JSONError"
-                        }
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "rss",
+                                "vimeo",
+                                "youtube"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by source types",
+                        "name": "sourceTypes",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/JSONError"
-                        }
-                    }
-                }
-            },
-            "patch": {
-                "security": [
                     {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "tags": [
-                    "langs"
-                ],
-                "parameters": [
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "filter contents by source id",
+                        "name": "sources",
+                        "in": "query"
+                    },
                     {
-                        "description": "Update body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/lang.UpdateBody"
-                        }
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "exclude the contents of these sources",
+                        "name": "excludeSources",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Lang ISO Code",
-                        "name": "isoCode",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "video",
+                                "article"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by type",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "regular",
+                                "short",
+                                "live",
+                                "upcoming",
+                                "premiere"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by sub type",
+                        "name": "subTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "filter contents by lang ISO code, the detected one or the source one",
+                        "name": "langs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "contents published at or after this time",
+                        "name": "publishedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "contents published before this time",
+                        "name": "publishedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "filter contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only list the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "order of the contents, best match first by default",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Fetch page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "card",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "fields of the contents: card, the default, leaves out the details and gives a compact source",
+                        "name": "projection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "fields of the contents, by their JSON name, instead of a projection",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Lang"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/Pagination"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/SearchResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
@@ -279,18 +498,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/refresh": {
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+        "/contents/stream": {
+            "get": {
+                "description": "Each content is sent as a \"content\" event. Reconnecting with the Last-Event-ID header sends the contents missed since this event first.\nThe contents ingested shortly before this event are sent again too, in case one was committed late: skip the content IDs already received. Comments are sent as heartbeats.",
+                "produces": [
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "refresh"
+                    "contents"
                 ],
-                "summary": "Refresh sources by there types",
+                "summary": "Stream the contents as they're ingested, as Server-Sent Events",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
                     {
                         "type": "array",
                         "items": {
@@ -301,27 +525,96 @@ const docTemplate = `{
                             ],
                             "type": "string"
                         },
-                        "description": "Type of sources to refresh",
+                        "description": "filter contents by source types",
+                        "name": "sourceTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "filter contents by source id",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "exclude the contents of these sources",
+                        "name": "excludeSources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "video",
+                                "article"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by type",
                         "name": "types",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "regular",
+                                "short",
+                                "live",
+                                "upcoming",
+                                "premiere"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "filter contents by sub type",
+                        "name": "subTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "filter contents by lang ISO code, the detected one or the source one",
+                        "name": "langs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "filter contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/Content"
-                                }
-                            }
+                            "$ref": "#/definitions/Content"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
@@ -329,28 +622,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/refresh/sync-feedly": {
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
+        "/contents/{contentId}": {
+            "get": {
                 "tags": [
-                    "refresh"
+                    "contents"
                 ],
-                "summary": "Query sources used in feedly and add missing ones in Scribe",
+                "summary": "Get one content by id",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/Source"
-                                }
-                            }
+                            "$ref": "#/definitions/Content"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
                         }
                     },
                     "500": {
@@ -360,9 +648,7 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/refresh/{sourceID}": {
+            },
             "patch": {
                 "security": [
                     {
@@ -370,35 +656,38 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "refresh"
+                    "contents"
                 ],
-                "summary": "Refresh a given source",
+                "summary": "Update a content, keeping the changes as revisions. Locked fields are kept by refreshes",
                 "parameters": [
+                    {
+                        "description": "Update body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/content.UpdateBody"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Source ID",
-                        "name": "sourceID",
+                        "description": "ID of the content",
+                        "name": "contentId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Will override content attributes",
-                        "name": "force",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/Source"
-                                }
-                            }
+                            "$ref": "#/definitions/Content"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
                         }
                     },
                     "500": {
@@ -410,26 +699,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/sources": {
+        "/contents/{contentId}/duplicates": {
             "get": {
                 "tags": [
-                    "sources"
+                    "contents"
                 ],
-                "summary": "Fetch all sources",
+                "summary": "Fetch the other contents of the group of duplicates of a content, canonical one included",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "enum": [
-                                "rss",
-                                "vimeo",
-                                "youtube"
-                            ],
-                            "type": "string"
-                        },
-                        "description": "Filter by source types",
-                        "name": "types",
-                        "in": "query"
+                        "type": "string",
+                        "description": "ID of the content",
+                        "name": "contentId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -440,45 +722,57 @@ const docTemplate = `{
                             "items": {
                                 "type": "array",
                                 "items": {
-                                    "$ref": "#/definitions/Source"
+                                    "$ref": "#/definitions/Content"
                                 }
                             }
                         }
                     },
-                    "500": {
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/contents/{contentId}/revisions": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
                 "tags": [
-                    "sources"
+                    "contents"
                 ],
-                "summary": "Add a new source",
+                "summary": "Fetch the history of a content, latest revision first",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/source.CreateBody"
-                        }
+                        "type": "string",
+                        "description": "ID of the content",
+                        "name": "contentId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Source"
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/ContentRevision"
+                                }
+                            }
                         }
                     },
                     "404": {
@@ -496,43 +790,114 @@ const docTemplate = `{
                 }
             }
         },
-        "/sources/order": {
-            "patch": {
+        "/contents/{contentId}/revisions/{revisionId}/revert": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
                 "tags": [
-                    "sources"
+                    "contents"
                 ],
-                "summary": "Update orders of the sources",
+                "summary": "Restore a content as it was right after the given revision",
                 "parameters": [
                     {
-                        "description": "Update body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "ID of the content",
+                        "name": "contentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the revision",
+                        "name": "revisionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/source.UpdateBody"
+                            "$ref": "#/definitions/Content"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
                         }
                     }
+                }
+            }
+        },
+        "/feeds/contents.{format}": {
+            "get": {
+                "produces": [
+                    "text/xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Feed of the latest contents, as RSS, Atom or JSON Feed",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rss",
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "format of the feed",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/Source"
-                                }
-                            }
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/JSONError"
                         }
@@ -546,17 +911,95 @@ const docTemplate = `{
                 }
             }
         },
-        "/sources/{sourceID}": {
-            "delete": {
-                "security": [
+        "/feeds/langs/{isoCode}/contents.{format}": {
+            "get": {
+                "produces": [
+                    "text/xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Feed of the latest contents in a lang, as RSS, Atom or JSON Feed",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "string",
+                        "description": "ISO code of the lang",
+                        "name": "isoCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "rss",
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "format of the feed",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
                     }
+                }
+            }
+        },
+        "/feeds/sources/{sourceID}/contents.{format}": {
+            "get": {
+                "produces": [
+                    "text/xml",
+                    "application/json"
                 ],
                 "tags": [
-                    "sources"
+                    "feeds"
                 ],
-                "summary": "Delete a source",
+                "summary": "Feed of the latest contents of a source, as RSS, Atom or JSON Feed",
                 "parameters": [
                     {
                         "type": "integer",
@@ -564,13 +1007,45 @@ const docTemplate = `{
                         "name": "sourceID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "rss",
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "format of the feed",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/JSONMessage"
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "404": {
@@ -586,40 +1061,80 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+            }
+        },
+        "/feeds/types/{type}/contents.{format}": {
+            "get": {
+                "produces": [
+                    "text/xml",
+                    "application/json"
                 ],
                 "tags": [
-                    "sources"
+                    "feeds"
                 ],
-                "summary": "Update a source",
+                "summary": "Feed of the latest videos or articles, as RSS, Atom or JSON Feed",
                 "parameters": [
                     {
-                        "description": "Update body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/source.UpdateBody"
-                        }
+                        "enum": [
+                            "video",
+                            "article"
+                        ],
+                        "type": "string",
+                        "description": "type of the contents",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "ID of the source",
-                        "name": "sourceID",
+                        "enum": [
+                            "rss",
+                            "atom",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "format of the feed",
+                        "name": "format",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the contents of skate sources",
+                        "name": "skateOnly",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "contents with a skate relevance at least this high",
+                        "name": "minRelevance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the canonical content of each group of duplicates",
+                        "name": "collapse",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Source"
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
                         }
                     },
                     "500": {
@@ -630,25 +1145,1696 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "Content": {
+        },
+        "/images/{imageId}": {
+            "get": {
+                "tags": [
+                    "images"
+                ],
+                "summary": "Get a mirrored image with its derivatives",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the image",
+                        "name": "imageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Image"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/{imageId}/{derivative}": {
+            "get": {
+                "description": "The WebP derivatives are only made by the builds with cgo, the derivatives of an image are listed by GET /images/{imageId}.",
+                "produces": [
+                    "image/jpeg",
+                    "image/webp"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Serve a derivative of a mirrored image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the image",
+                        "name": "imageId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "small.jpg",
+                            "small.webp",
+                            "medium.jpg",
+                            "medium.webp",
+                            "large.jpg",
+                            "large.webp"
+                        ],
+                        "type": "string",
+                        "description": "Size and format",
+                        "name": "derivative",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/langs": {
+            "get": {
+                "tags": [
+                    "langs"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/Lang"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "langs"
+                ],
+                "parameters": [
+                    {
+                        "description": "Create body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lang.CreateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Lang"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/langs/{isoCode}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "langs"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lang ISO Code",
+                        "name": "isoCode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/JSONMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "langs"
+                ],
+                "parameters": [
+                    {
+                        "description": "Update body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lang.UpdateBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Lang ISO Code",
+                        "name": "isoCode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Lang"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Refresh sources by there types",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "rss",
+                                "vimeo",
+                                "youtube"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "Type of sources to refresh",
+                        "name": "types",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return what would change, without saving",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RefreshDiff"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh/pipeline": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Get the processors the fetched contents go through before being saved",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PipelineConfig"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Replace the processors the fetched contents go through, in order",
+                "parameters": [
+                    {
+                        "description": "Pipeline",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PipelineConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PipelineConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh/sync-feedly": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Query sources used in feedly and add missing ones in Scribe",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only return the sources that would be added, without saving",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RefreshDiff"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh/{sourceID}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "refresh"
+                ],
+                "summary": "Refresh a given source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID",
+                        "name": "sourceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Will override content attributes",
+                        "name": "force",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return what would change, without saving",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RefreshDiff"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/relevance/dictionary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "relevance"
+                ],
+                "summary": "Get the dictionary used to score the skate relevance of contents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RelevanceDictionary"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "relevance"
+                ],
+                "summary": "Replace the dictionary used to score the skate relevance of new contents",
+                "parameters": [
+                    {
+                        "description": "Dictionary",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RelevanceDictionary"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RelevanceDictionary"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources": {
+            "get": {
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Fetch all sources",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "rss",
+                                "vimeo",
+                                "youtube"
+                            ],
+                            "type": "string"
+                        },
+                        "description": "Filter by source types",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Fetch these sources instead, the response is a SourceBatch",
+                        "name": "ids",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/Source"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Add a new source",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/source.CreateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Source"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/batch": {
+            "post": {
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Fetch up to 100 sources by ID, in the order of the IDs, along with the IDs not found",
+                "parameters": [
+                    {
+                        "description": "IDs of the sources",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/source.BatchBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SourceBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/order": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Update orders of the sources",
+                "parameters": [
+                    {
+                        "description": "Update body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/source.UpdateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/Source"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{sourceID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Delete a source",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the source",
+                        "name": "sourceID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/JSONMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Update a source",
+                "parameters": [
+                    {
+                        "description": "Update body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/source.UpdateBody"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the source",
+                        "name": "sourceID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Source"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "description": "Without token every lang and source is sent, along with the contents of the last 30 days.\nDeleted resources are sent with their deletedAt set. When more is true, sync again right away with the new token.",
+                "tags": [
+                    "sync"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token returned by the previous sync",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of changes of each resource, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SyncResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/Webhook"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deliveries are POSTed with the X-Scribe-Event, X-Scribe-Delivery and X-Scribe-Timestamp headers.\nX-Scribe-Signature is sha256= followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret.\nFailed deliveries are retried with an exponential backoff.\nThe secret is only returned by this call, keep it to check the signatures.",
+                "tags": [
+                    "webhooks"
+                ],
+                "parameters": [
+                    {
+                        "description": "Create body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.CreateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CreatedWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/JSONMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "parameters": [
+                    {
+                        "description": "Update body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.UpdateBody"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/Pagination"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/JSONError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "CompactSource": {
+            "type": "object",
+            "properties": {
+                "iconImage": {
+                    "$ref": "#/definitions/Image"
+                },
+                "iconUrl": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "shortTitle": {
+                    "type": "string"
+                },
+                "skateSource": {
+                    "type": "boolean"
+                },
+                "sourceType": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "websiteUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "Content": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "For feedly article",
+                    "type": "string"
+                },
+                "canonicalUrl": {
+                    "description": "URL of the article given by its page",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "contentId": {
+                    "description": "Youtube or Vimeo ID or Feedly ID",
+                    "type": "string"
+                },
+                "contentUrl": {
+                    "description": "Youtube or Vimeo video url or article URL",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "description": "Order of the contents stream",
+                    "type": "string"
+                },
+                "definition": {
+                    "description": "hd or sd",
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "duplicateOfId": {
+                    "description": "Canonical content of its group, null for a canonical content",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "Video details and statistics",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "langConfidence": {
+                    "type": "number"
+                },
+                "langIsoCode": {
+                    "description": "Detected from the title and summary, empty when the detection wasn't reliable",
+                    "type": "string"
+                },
+                "likeCount": {
+                    "type": "integer"
+                },
+                "lockedFields": {
+                    "description": "Fields kept by refreshes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "publishedAt": {
+                    "description": "Contents feed order, with the ID",
+                    "type": "string"
+                },
+                "rawContent": {
+                    "type": "string"
+                },
+                "rawSummary": {
+                    "type": "string"
+                },
+                "relevance": {
+                    "description": "How much it's about skateboarding, between 0 and 1. Null when it hasn't been scored",
+                    "type": "number"
+                },
+                "source": {
+                    "$ref": "#/definitions/Source"
+                },
+                "statsUpdatedAt": {
+                    "type": "string"
+                },
+                "subType": {
+                    "description": "regular, short, live, upcoming or premiere",
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "thumbnailImage": {
+                    "description": "Mirrored thumbnail, null until mirrored",
+                    "$ref": "#/definitions/Image"
+                },
+                "thumbnailUrl": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "description": "Only changed when a field the clients show changes, for the sync",
+                    "type": "string"
+                },
+                "viewCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ContentBatch": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "In the order of the IDs",
+                    "type": "array",
+                    "items": {}
+                },
+                "missing": {
+                    "description": "IDs without content",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ContentCard": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "contentId": {
+                    "type": "string"
+                },
+                "contentUrl": {
+                    "type": "string"
+                },
+                "duplicateOfId": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "langIsoCode": {
+                    "type": "string"
+                },
+                "likeCount": {
+                    "type": "integer"
+                },
+                "publishedAt": {
+                    "type": "string"
+                },
+                "relevance": {
+                    "type": "number"
+                },
+                "source": {
+                    "$ref": "#/definitions/CompactSource"
+                },
+                "subType": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "thumbnailImage": {
+                    "$ref": "#/definitions/Image"
+                },
+                "thumbnailUrl": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "viewCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ContentChange": {
+            "type": "object",
+            "properties": {
+                "contentId": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ContentRevision": {
+            "type": "object",
+            "properties": {
+                "cause": {
+                    "description": "refresh, force, manual or revert",
+                    "type": "string"
+                },
+                "contentId": {
+                    "description": "Scribe ID of the content",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "newValue": {
+                    "type": "string"
+                },
+                "oldValue": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreatedWebhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filters": {
+                    "$ref": "#/definitions/WebhookFilters"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "CursorPagination": {
+            "type": "object",
+            "properties": {
+                "items": {},
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "Null on the last page",
+                    "type": "string"
+                },
+                "prev": {
+                    "description": "Null on the first page",
+                    "type": "string"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                }
+            }
+        },
+        "Image": {
+            "type": "object",
+            "properties": {
+                "blurHash": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "derivatives": {
+                    "description": "Available derivatives, like medium.webp, served at /images/:id/:derivative",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Hash of the source URL",
+                    "type": "string"
+                },
+                "sourceUrl": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "IngestRules": {
+            "type": "object",
+            "properties": {
+                "excludeKeywords": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "excludePatterns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "includeKeywords": {
+                    "description": "When set, the title or summary has to contain one of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "includePatterns": {
+                    "description": "Regular expressions matched against the title and the summary",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "maxAge": {
+                    "description": "In days",
+                    "type": "integer"
+                },
+                "minDuration": {
+                    "description": "In seconds, for the videos whose duration is known",
+                    "type": "integer"
+                }
+            }
+        },
+        "JSONError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "JSONMessage": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "Lang": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "imageUrl": {
+                    "type": "string"
+                },
+                "isoCode": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "Pagination": {
+            "type": "object",
+            "properties": {
+                "items": {},
+                "page": {
+                    "type": "integer"
+                },
+                "perPage": {
+                    "type": "integer"
+                },
+                "totalPages": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "PipelineConfig": {
+            "type": "object",
+            "properties": {
+                "processors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ProcessorConfig"
+                    }
+                }
+            }
+        },
+        "ProcessorConfig": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "failOnError": {
+                    "description": "Abort the refresh instead of going on without the processor",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "RefreshDiff": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "newContents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Content"
+                    }
+                },
+                "newSources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Source"
+                    }
+                },
+                "rejected": {
+                    "description": "New contents not following the ingest rules of their source",
+                    "type": "integer"
+                },
+                "rejectedContents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/RejectedContent"
+                    }
+                },
+                "updatedContents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ContentChange"
+                    }
+                },
+                "updatedSources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SourceChange"
+                    }
+                }
+            }
+        },
+        "RejectedContent": {
             "type": "object",
             "properties": {
-                "author": {
-                    "description": "For feedly article",
+                "contentId": {
                     "type": "string"
                 },
-                "content": {
+                "processor": {
                     "type": "string"
                 },
-                "contentId": {
-                    "description": "Youtube or Vimeo ID or Feedly ID",
+                "reason": {
                     "type": "string"
                 },
-                "contentUrl": {
-                    "description": "Youtube or Vimeo video url or article URL",
+                "sourceId": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "RelevanceDictionary": {
+            "type": "object",
+            "properties": {
+                "keywordWeight": {
+                    "description": "Weight multiplier of the terms found in the keywords",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "otherSourcePrior": {
+                    "type": "number"
+                },
+                "skateSourcePrior": {
+                    "description": "Score of a content of a skate source matching no term",
+                    "type": "number"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/RelevanceTerm"
+                    }
+                },
+                "titleWeight": {
+                    "description": "Weight multiplier of the terms found in the title",
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "RelevanceTerm": {
+            "type": "object",
+            "required": [
+                "phrase"
+            ],
+            "properties": {
+                "phrase": {
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Negative for terms of other sports",
+                    "type": "number"
+                }
+            }
+        },
+        "SearchResult": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "$ref": "#/definitions/Content"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "HTML of parts of the summary, or of the body without summary, with the matches in \u003cmark\u003e tags",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "HTML of the title, with the matches in \u003cmark\u003e tags",
+                    "type": "string"
+                }
+            }
+        },
+        "Source": {
+            "type": "object",
+            "properties": {
+                "coverUrl": {
                     "type": "string"
                 },
                 "createdAt": {
@@ -657,145 +2843,302 @@ const docTemplate = `{
                 "deletedAt": {
                     "type": "string"
                 },
-                "id": {
+                "description": {
+                    "type": "string"
+                },
+                "iconImage": {
+                    "description": "Mirrored icon, null until mirrored",
+                    "$ref": "#/definitions/Image"
+                },
+                "iconUrl": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "ingestRules": {
+                    "description": "Fetched contents not following them aren't saved",
+                    "$ref": "#/definitions/IngestRules"
+                },
+                "lang": {
+                    "$ref": "#/definitions/Lang"
+                },
+                "lockedContentFields": {
+                    "description": "Content fields kept by refreshes, for every content of the source",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lockedFields": {
+                    "description": "Source fields kept by the feedly sync",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "order": {
+                    "type": "integer"
+                },
                 "publishedAt": {
                     "type": "string"
                 },
-                "rawContent": {
+                "refreshedAt": {
                     "type": "string"
                 },
-                "rawSummary": {
+                "shortTitle": {
                     "type": "string"
                 },
-                "source": {
-                    "$ref": "#/definitions/Source"
+                "skateSource": {
+                    "type": "boolean"
                 },
-                "summary": {
+                "sourceId": {
+                    "description": "Vimeo, Youtube or Feedly ID, depending on the type",
                     "type": "string"
                 },
-                "thumbnailUrl": {
+                "sourceType": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
-                "type": {
+                "updatedAt": {
+                    "type": "string"
+                },
+                "websiteUrl": {
                     "type": "string"
                 }
             }
         },
-        "JSONError": {
+        "SourceBatch": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "items": {
+                    "description": "In the order of the IDs",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Source"
+                    }
                 },
-                "message": {
-                    "type": "string"
+                "missing": {
+                    "description": "IDs without source",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "JSONMessage": {
+        "SourceChange": {
             "type": "object",
             "properties": {
-                "message": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "sourceId": {
                     "type": "string"
                 }
             }
         },
-        "Lang": {
+        "SyncResult": {
             "type": "object",
             "properties": {
-                "createdAt": {
-                    "type": "string"
+                "contents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Content"
+                    }
                 },
-                "deletedAt": {
-                    "type": "string"
+                "langs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Lang"
+                    }
                 },
-                "imageUrl": {
-                    "type": "string"
+                "more": {
+                    "description": "Some changes didn't fit, sync again right away with the token",
+                    "type": "boolean"
                 },
-                "isoCode": {
-                    "type": "string"
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Source"
+                    }
                 },
-                "updatedAt": {
+                "token": {
+                    "description": "To send on the next sync",
                     "type": "string"
                 }
             }
         },
-        "Pagination": {
+        "Webhook": {
             "type": "object",
             "properties": {
-                "items": {},
-                "page": {
-                    "type": "integer"
+                "createdAt": {
+                    "type": "string"
                 },
-                "perPage": {
-                    "type": "integer"
+                "deletedAt": {
+                    "type": "string"
                 },
-                "totalPages": {
-                    "type": "integer"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "totalResults": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filters": {
+                    "$ref": "#/definitions/WebhookFilters"
+                },
+                "id": {
                     "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "Source": {
+        "WebhookDelivery": {
             "type": "object",
             "properties": {
-                "coverUrl": {
-                    "type": "string"
+                "attempts": {
+                    "type": "integer"
                 },
                 "createdAt": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "type": "string"
-                },
-                "description": {
+                "deliveredAt": {
                     "type": "string"
                 },
-                "iconUrl": {
+                "event": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "lang": {
-                    "$ref": "#/definitions/Lang"
+                "lastError": {
+                    "type": "string"
                 },
-                "order": {
+                "lastStatusCode": {
+                    "description": "0 when the webhook couldn't be reached",
                     "type": "integer"
                 },
-                "publishedAt": {
+                "nextAttemptAt": {
                     "type": "string"
                 },
-                "refreshedAt": {
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "description": "pending, delivered or failed",
                     "type": "string"
                 },
-                "shortTitle": {
+                "updatedAt": {
                     "type": "string"
                 },
-                "skateSource": {
+                "webhookId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "WebhookFilters": {
+            "type": "object",
+            "properties": {
+                "langs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "minRelevance": {
+                    "type": "number"
+                },
+                "skateOnly": {
                     "type": "boolean"
                 },
-                "sourceId": {
-                    "description": "Vimeo, Youtube or Feedly ID, depending on the type",
+                "sourceTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "types": {
+                    "description": "video or article",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "content.BatchBody": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "projection": {
+                    "type": "string",
+                    "enum": [
+                        "card",
+                        "full"
+                    ]
+                }
+            }
+        },
+        "content.UpdateBody": {
+            "type": "object",
+            "properties": {
+                "lockedFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "publishedAt": {
                     "type": "string"
                 },
-                "sourceType": {
+                "rawSummary": {
                     "type": "string"
                 },
-                "title": {
+                "summary": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "thumbnailUrl": {
                     "type": "string"
                 },
-                "websiteUrl": {
+                "title": {
                     "type": "string"
                 }
             }
@@ -826,6 +3169,22 @@ const docTemplate = `{
                 }
             }
         },
+        "source.BatchBody": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "source.CreateBody": {
             "type": "object",
             "required": [
@@ -863,12 +3222,27 @@ const docTemplate = `{
                 "iconURL": {
                     "type": "string"
                 },
+                "ingestRules": {
+                    "$ref": "#/definitions/IngestRules"
+                },
                 "isSkateSource": {
                     "type": "boolean"
                 },
                 "lang": {
                     "type": "string"
                 },
+                "lockedContentFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lockedFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "shortTitle": {
                     "type": "string"
                 },
@@ -879,6 +3253,62 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "webhook.CreateBody": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "enabled": {
+                    "description": "Defaults to true",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filters": {
+                    "$ref": "#/definitions/WebhookFilters"
+                },
+                "secret": {
+                    "description": "Generated when empty",
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.UpdateBody": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filters": {
+                    "$ref": "#/definitions/WebhookFilters"
+                },
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
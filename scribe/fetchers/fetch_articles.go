@@ -0,0 +1,270 @@
+package fetchers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skatekrak/scribe/internal/metadata"
+	"github.com/skatekrak/scribe/internal/readability"
+)
+
+// Number of article pages fetched at the same time
+const articleWorkers = 8
+
+// Pages bigger than this are cut before extraction
+const maxArticleSize = 5 << 20
+
+// Time between two requests to the same domain
+const domainInterval = time.Second
+
+// How long a fetched page is kept, failures are retried sooner
+const (
+	pageCacheTTL      = 6 * time.Hour
+	pageErrorCacheTTL = 30 * time.Minute
+	pageCacheSize     = 2000
+)
+
+var articleClient = &http.Client{Timeout: 15 * time.Second}
+
+var (
+	pages   = newPageCache(pageCacheSize)
+	domains = newDomainLimiter(domainInterval)
+)
+
+// What we read from an article page
+type Page struct {
+	RawContent string // Main content as HTML, empty if none was found
+	Content    string
+	Metadata   metadata.Metadata
+}
+
+// Fetch the page of an article, extract its main content and read its metadata.
+// Pages are cached and requests to a same domain are spaced out.
+func (fe *Fetcher) FetchPage(url string) (*Page, error) {
+	if cached, ok := pages.get(url); ok {
+		return cached.page, cached.err
+	}
+
+	page, err := fetchPage(url)
+	pages.set(url, page, err)
+	return page, err
+}
+
+func fetchPage(url string) (*Page, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scribe/1.0; +https://github.com/skatekrak/scribe)")
+	req.Header.Set("Accept", "text/html")
+
+	domains.wait(u.Hostname())
+
+	response, err := articleClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching article: %s", response.Status)
+	}
+
+	if !strings.Contains(response.Header.Get("Content-Type"), "html") {
+		return nil, errors.New("article isn't an html page")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxArticleSize))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+
+	// Use the final URL, after redirects, to resolve the links
+	pageURL := response.Request.URL.String()
+
+	meta, err := metadata.Parse(bytes.NewReader(body), pageURL)
+	if err != nil {
+		return nil, err
+	}
+	page.Metadata = *meta
+
+	page.RawContent, page.Content, err = readability.Extract(bytes.NewReader(body), pageURL)
+	if err != nil && !errors.Is(err, readability.ErrNoContent) {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// A summary cut by the feed, that the page may have in full
+func isTruncated(summary string) bool {
+	summary = strings.TrimSpace(summary)
+	return summary == "" || strings.HasSuffix(summary, "…") || strings.HasSuffix(summary, "...") || strings.HasSuffix(summary, "[…]")
+}
+
+func needsPage(content *ContentFetchData) bool {
+	return content.RawContent == "" ||
+		content.ThumbnailURL == "" ||
+		content.Author == "" ||
+		content.CanonicalURL == "" ||
+		isTruncated(content.Description)
+}
+
+// Fill what the feed didn't give for the articles: body, thumbnail, summary,
+// author and canonical URL, from their page. Articles whose page can't be
+// fetched are left as they are.
+func (fe *Fetcher) EnrichArticles(contents []*ContentFetchData) {
+	queue := make(chan *ContentFetchData)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < articleWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for content := range queue {
+				page, err := fe.FetchPage(content.ContentURL)
+				if err != nil {
+					log.Printf("Couldn't fetch article %s: %s", content.ContentURL, err)
+					continue
+				}
+
+				enrich(content, page)
+			}
+		}()
+	}
+
+	for _, content := range contents {
+		if content.ContentURL != "" && needsPage(content) {
+			queue <- content
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+}
+
+func enrich(content *ContentFetchData, page *Page) {
+	if content.RawContent == "" {
+		content.RawContent = page.RawContent
+		content.Content = page.Content
+	}
+
+	if content.ThumbnailURL == "" {
+		content.ThumbnailURL = page.Metadata.ImageURL
+	}
+
+	if content.Author == "" {
+		content.Author = page.Metadata.Author
+	}
+
+	if content.CanonicalURL == "" {
+		content.CanonicalURL = page.Metadata.CanonicalURL
+	}
+
+	description := strings.TrimSpace(page.Metadata.Description)
+	if isTruncated(content.Description) && len(description) > len(strings.TrimSpace(content.Description)) {
+		content.Description = description
+		content.RawDescription = html.EscapeString(description)
+	}
+}
+
+type cachedPage struct {
+	page      *Page
+	err       error
+	expiresAt time.Time
+}
+
+// Fetched pages by URL
+type pageCache struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]cachedPage
+}
+
+func newPageCache(size int) *pageCache {
+	return &pageCache{size: size, entries: map[string]cachedPage{}}
+}
+
+func (c *pageCache) get(url string) (cachedPage, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[url]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cachedPage{}, false
+	}
+	return entry, true
+}
+
+func (c *pageCache) set(url string, page *Page, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.size {
+		// Drop the expired pages, or everything when none has expired yet
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.size {
+			c.entries = map[string]cachedPage{}
+		}
+	}
+
+	ttl := pageCacheTTL
+	if err != nil {
+		ttl = pageErrorCacheTTL
+	}
+	c.entries[url] = cachedPage{page, err, now.Add(ttl)}
+}
+
+// Space out the requests made to a same domain
+type domainLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newDomainLimiter(interval time.Duration) *domainLimiter {
+	return &domainLimiter{interval: interval, next: map[string]time.Time{}}
+}
+
+// Block until a request can be made to the domain
+func (l *domainLimiter) wait(domain string) {
+	l.mutex.Lock()
+	now := time.Now()
+	for d, at := range l.next {
+		if at.Before(now) {
+			delete(l.next, d)
+		}
+	}
+
+	at := l.next[domain]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[domain] = at.Add(l.interval)
+	l.mutex.Unlock()
+
+	time.Sleep(time.Until(at))
+}
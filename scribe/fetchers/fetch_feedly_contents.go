@@ -32,10 +32,15 @@ func (fe *Fetcher) FetchFeedlyContents(categoryID string) ([]ContentFetchData, e
 			Title:          item.Title,
 			Description:    html2text.HTML2Text(item.Summary.Content),
 			RawDescription: item.Summary.Content,
+			RawContent:     item.Content.Content,
+			Content:        html2text.HTML2Text(item.Content.Content),
 			PublishedAt:    time.UnixMilli(int64(item.Published)),
 			ThumbnailURL:   item.Visual.URL,
 			ContentID:      item.ID,
 			ContentURL:     url,
+			CanonicalURL:   item.CanonicalURL,
+			Author:         item.Author,
+			Keywords:       item.Keywords,
 			SourceID:       item.Origin.StreamID,
 		}
 	}
@@ -0,0 +1,189 @@
+package fetchers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skatekrak/scribe/clients/vimeo"
+	"github.com/skatekrak/scribe/clients/youtube"
+)
+
+// Kinds of videos
+const (
+	SubTypeRegular  = "regular"
+	SubTypeShort    = "short"
+	SubTypeLive     = "live"
+	SubTypeUpcoming = "upcoming"
+	SubTypePremiere = "premiere"
+)
+
+// The API doesn't tell the shorts apart. Videos up to a minute are considered
+// as shorts, and the ones up to 3 minutes, the longest shorts, only when they
+// have a #shorts hashtag. Untagged shorts over a minute pass as regular videos
+const (
+	maxUntaggedShortDuration = 60
+	maxShortDuration         = 180
+)
+
+// Details of a video that aren't in the channel listings
+type VideoDetails struct {
+	Duration   int // In seconds
+	ViewCount  *int64
+	LikeCount  *int64
+	Tags       []string
+	Definition string // hd or sd
+	SubType    string
+	StartedAt  *time.Time // When a live stream or premiere actually started
+}
+
+// Fetch the details of the given videos, by video ID
+func (fe *Fetcher) FetchVideosDetails(videoIDs []string, sourceType string) (map[string]VideoDetails, error) {
+	switch sourceType {
+	case "youtube":
+		return fe.fetchYoutubeVideosDetails(videoIDs)
+	case "vimeo":
+		return fe.fetchVimeoVideosDetails(videoIDs)
+	default:
+		return map[string]VideoDetails{}, errors.New("sourceType not supported")
+	}
+}
+
+func (fe *Fetcher) fetchYoutubeVideosDetails(videoIDs []string) (map[string]VideoDetails, error) {
+	details := make(map[string]VideoDetails, len(videoIDs))
+
+	for _, ids := range chunk(videoIDs, youtube.MaxVideoIDs) {
+		data, err := fe.y.FetchVideosDetails(ids)
+		if err != nil {
+			return details, err
+		}
+
+		for _, item := range data.Items {
+			details[item.ID] = youtubeVideoDetails(item)
+		}
+	}
+
+	return details, nil
+}
+
+func (fe *Fetcher) fetchVimeoVideosDetails(videoIDs []string) (map[string]VideoDetails, error) {
+	details := make(map[string]VideoDetails, len(videoIDs))
+
+	for _, ids := range chunk(videoIDs, vimeo.MaxVideoIDs) {
+		data, err := fe.v.FetchVideosByIDs(ids)
+		if err != nil {
+			return details, err
+		}
+
+		for _, item := range data.Data {
+			details[strings.ReplaceAll(item.URI, "/videos/", "")] = vimeoVideoDetails(item)
+		}
+	}
+
+	return details, nil
+}
+
+func youtubeVideoDetails(item youtube.VideoDetailsItem) VideoDetails {
+	duration, _ := youtube.ParseDuration(item.ContentDetails.Duration)
+
+	details := VideoDetails{
+		Duration:   duration,
+		ViewCount:  parseCount(item.Statistics.ViewCount),
+		LikeCount:  parseCount(item.Statistics.LikeCount),
+		Tags:       item.Snippet.Tags,
+		Definition: item.ContentDetails.Definition,
+		SubType:    youtubeSubType(item, duration),
+	}
+
+	if item.LiveStreamingDetails != nil {
+		details.StartedAt = item.LiveStreamingDetails.ActualStartTime
+	}
+
+	return details
+}
+
+func youtubeSubType(item youtube.VideoDetailsItem, duration int) string {
+	// Premieres are uploaded videos, they already have a duration before being aired
+	switch item.Snippet.LiveBroadcastContent {
+	case "upcoming":
+		if duration > 0 {
+			return SubTypePremiere
+		}
+		return SubTypeUpcoming
+	case "live":
+		if duration > 0 {
+			return SubTypePremiere
+		}
+		return SubTypeLive
+	}
+
+	// Ended live streams and premieres are regular videos from now on
+	if item.LiveStreamingDetails != nil || duration <= 0 || duration > maxShortDuration {
+		return SubTypeRegular
+	}
+	if duration <= maxUntaggedShortDuration || hasShortsHashtag(item.Snippet) {
+		return SubTypeShort
+	}
+
+	return SubTypeRegular
+}
+
+func vimeoVideoDetails(item vimeo.VimeoVideoItem) VideoDetails {
+	tags := make([]string, len(item.Tags))
+	for i, tag := range item.Tags {
+		tags[i] = tag.Name
+	}
+
+	definition := "sd"
+	if item.Height >= 720 {
+		definition = "hd"
+	}
+
+	likes := item.Metadata.Connections.Likes.Total
+
+	subType := SubTypeRegular
+	if item.Type == "live" {
+		subType = SubTypeLive
+	}
+
+	return VideoDetails{
+		Duration:   item.Duration,
+		ViewCount:  item.Stats.Plays,
+		LikeCount:  &likes,
+		Tags:       tags,
+		Definition: definition,
+		SubType:    subType,
+	}
+}
+
+func parseCount(count string) *int64 {
+	n, err := strconv.ParseInt(count, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+func chunk(s []string, size int) [][]string {
+	chunks := [][]string{}
+	for start := 0; start < len(s); start += size {
+		end := start + size
+		if end > len(s) {
+			end = len(s)
+		}
+		chunks = append(chunks, s[start:end])
+	}
+	return chunks
+}
+
+func hasShortsHashtag(snippet youtube.VideoDetailsSnippet) bool {
+	for _, tag := range snippet.Tags {
+		if strings.EqualFold(strings.TrimPrefix(tag, "#"), "shorts") {
+			return true
+		}
+	}
+
+	text := strings.ToLower(snippet.Title + " " + snippet.Description)
+	return strings.Contains(text, "#shorts")
+}
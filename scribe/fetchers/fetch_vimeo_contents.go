@@ -24,6 +24,7 @@ func (fe *Fetcher) FetchVimeoChannelContents(userID string) ([]ContentFetchData,
 			ThumbnailURL:   vimeo.GetLargerImageLink(item.Pictures.Sizes),
 			ContentID:      strings.ReplaceAll(item.URI, "/videos/", ""),
 			ContentURL:     fmt.Sprintf("https://vimeo.com/%s", strings.ReplaceAll(item.URI, "/videos/", "")),
+			VideoDetails:   vimeoVideoDetails(item),
 		}
 	}
 
@@ -2,6 +2,7 @@ package fetchers
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/k3a/html2text"
 	"github.com/skatekrak/scribe/clients/youtube"
@@ -14,6 +15,17 @@ func (fe *Fetcher) FetchYoutubeChannelContents(channelID string) ([]ContentFetch
 	}
 
 	items := make([]ContentFetchData, len(data.Items))
+	videoIDs := make([]string, len(data.Items))
+
+	for i, item := range data.Items {
+		videoIDs[i] = item.ID.VideoID
+	}
+
+	// Search results don't have the statistics, they need a second request
+	details, err := fe.fetchYoutubeVideosDetails(videoIDs)
+	if err != nil {
+		log.Printf("Couldn't fetch details of the videos of %s: %s", channelID, err)
+	}
 
 	for i, item := range data.Items {
 		items[i] = ContentFetchData{
@@ -24,6 +36,7 @@ func (fe *Fetcher) FetchYoutubeChannelContents(channelID string) ([]ContentFetch
 			ThumbnailURL:   youtube.GetBestThumbnail(item.Snippet.Thumbnails),
 			ContentID:      item.ID.VideoID,
 			ContentURL:     fmt.Sprintf("https://youtube.com/watch?=%s", item.ID.VideoID),
+			VideoDetails:   details[item.ID.VideoID],
 		}
 	}
 
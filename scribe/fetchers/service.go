@@ -26,10 +26,17 @@ type ContentFetchData struct {
 	PublishedAt    time.Time
 	Description    string // Or Summary
 	RawDescription string // or RawSummary
+	RawContent     string // Full HTML body of an article
+	Content        string
 	ThumbnailURL   string
 	ContentID      string // or VideoID
 	ContentURL     string
+	CanonicalURL   string
+	Author         string
+	Keywords       []string // Feedly keywords of an article
 	SourceID       string
+
+	VideoDetails
 }
 
 type Fetcher struct {
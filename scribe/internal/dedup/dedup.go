@@ -0,0 +1,155 @@
+// Near-duplicate detection of contents: normalized URLs, title similarity
+// and simhash of the summaries
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Query parameters only used for tracking
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref":     true,
+	"ref_src": true,
+	"si":      true,
+}
+
+// NormalizeURL returns the URL without scheme, www, fragment, trailing slash
+// and tracking parameters, so the same page gets the same URL. Returns an
+// empty string for an invalid URL.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			query.Del(key)
+		}
+	}
+
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+
+	return normalized
+}
+
+// Words of the text, lower cased
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// TitleSimilarity is the Jaccard similarity of the words of both titles, between 0 and 1
+func TitleSimilarity(a string, b string) float64 {
+	setA := map[string]bool{}
+	for _, w := range words(a) {
+		setA[w] = true
+	}
+	setB := map[string]bool{}
+	for _, w := range words(b) {
+		setB[w] = true
+	}
+
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	common := 0
+	for w := range setA {
+		if setB[w] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// Simhash of the text, computed on shingles of 3 words. Close texts get
+// hashes with a small Hamming distance. Returns 0 for texts too short.
+func Simhash(text string) uint64 {
+	w := words(text)
+	if len(w) < 3 {
+		return 0
+	}
+
+	var weights [64]int
+	for i := 0; i+3 <= len(w); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(w[i:i+3], " ")))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
+
+// Distance is the number of bits that differ between two simhashes
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// What is compared between two contents
+type Fingerprint struct {
+	SourceID      uint // 0 when unknown
+	NormalizedURL string
+	Title         string
+	Simhash       uint64
+}
+
+// Titles shorter than that, like "Full part", aren't enough to tell two contents apart
+const minTitleWords = 4
+
+// IsDuplicate tells whether both contents are very likely the same. The
+// contents of a same source never are, like the parts of a series
+func IsDuplicate(a Fingerprint, b Fingerprint) bool {
+	if a.SourceID != 0 && a.SourceID == b.SourceID {
+		return false
+	}
+
+	if a.NormalizedURL != "" && a.NormalizedURL == b.NormalizedURL {
+		return true
+	}
+
+	similarity := TitleSimilarity(a.Title, b.Title)
+	if similarity >= 0.9 && len(words(a.Title)) >= minTitleWords && len(words(b.Title)) >= minTitleWords {
+		return true
+	}
+
+	if a.Simhash == 0 || b.Simhash == 0 {
+		return false
+	}
+
+	distance := Distance(a.Simhash, b.Simhash)
+	return distance <= 3 || (distance <= 8 && similarity >= 0.5)
+}
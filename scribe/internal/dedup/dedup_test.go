@@ -0,0 +1,65 @@
+package dedup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	require.Equal(t, "thrashermagazine.com/articles/videos/part", NormalizeURL("https://www.thrashermagazine.com/articles/videos/part/?utm_source=feedly&utm_medium=rss#comments"))
+	require.Equal(t, "example.com/news?id=12", NormalizeURL("http://m.example.com/news?id=12&fbclid=abc"))
+	require.Equal(t, "", NormalizeURL("not a url"))
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, TitleSimilarity("Tyshawn Jones - Full Part", "TYSHAWN JONES: full part"))
+	require.Less(t, TitleSimilarity("Weekly clips #12", "Weekly clips #13"), 0.6)
+	require.Equal(t, 0.0, TitleSimilarity("", "Full part"))
+}
+
+func TestSimhash(t *testing.T) {
+	summary := "Street League returns to Tokyo this summer with the best street skaters of the world competing for the title over three days of contest"
+	syndicated := "Street League returns to Tokyo this summer with the best street skaters of the world competing for the title over three days of contest. Read more"
+	other := "A new skatepark opened downtown with ledges, rails and a big bowl built by the city after years of petitions from local skaters"
+
+	require.LessOrEqual(t, Distance(Simhash(summary), Simhash(syndicated)), 8)
+	require.Greater(t, Distance(Simhash(summary), Simhash(other)), 8)
+	require.Equal(t, uint64(0), Simhash("too short"))
+}
+
+func TestIsDuplicate(t *testing.T) {
+	summary := Simhash("Street League returns to Tokyo this summer with the best street skaters of the world competing for the title over three days of contest")
+
+	t.Run("same page", func(t *testing.T) {
+		require.True(t, IsDuplicate(
+			Fingerprint{NormalizedURL: "example.com/news", Title: "Street League in Tokyo"},
+			Fingerprint{NormalizedURL: "example.com/news", Title: "SLS heads to Japan"},
+		))
+	})
+
+	t.Run("same long title", func(t *testing.T) {
+		require.True(t, IsDuplicate(
+			Fingerprint{Title: "Tyshawn Jones 'Hockey 4' Part"},
+			Fingerprint{Title: "Hockey 4 - Tyshawn Jones Part"},
+		))
+	})
+
+	t.Run("same short title", func(t *testing.T) {
+		require.False(t, IsDuplicate(Fingerprint{Title: "Full part"}, Fingerprint{Title: "Full Part"}))
+	})
+
+	t.Run("same source", func(t *testing.T) {
+		require.False(t, IsDuplicate(
+			Fingerprint{SourceID: 1, NormalizedURL: "example.com/news", Title: "Street League in Tokyo", Simhash: summary},
+			Fingerprint{SourceID: 1, NormalizedURL: "example.com/news", Title: "Street League in Tokyo", Simhash: summary},
+		))
+	})
+
+	t.Run("syndicated summary", func(t *testing.T) {
+		require.True(t, IsDuplicate(
+			Fingerprint{Title: "Street League returns", Simhash: summary},
+			Fingerprint{Title: "SLS is back", Simhash: summary},
+		))
+	})
+}
@@ -0,0 +1,163 @@
+// Cache of the public GET responses, tagged by the resources they show so
+// the mutations of a resource purge them
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Resources the responses depend on
+const (
+	TagContents = "contents"
+	TagSources  = "sources"
+	TagLangs    = "langs"
+)
+
+type Cache struct {
+	store Store
+	ttl   time.Duration
+}
+
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store, ttl}
+}
+
+// Make the cached responses of the resources stale
+func (c *Cache) Purge(tags ...string) {
+	if err := c.store.Purge(tags...); err != nil {
+		log.Printf("Couldn't purge the cache of %v: %s", tags, err)
+	}
+}
+
+type Config struct {
+	// Resources a GET response depends on. Responses without tags aren't cached
+	Tags func(ctx *fiber.Ctx) []string
+	// Resources changed by a successful mutation
+	PurgedTags func(ctx *fiber.Ctx) []string
+}
+
+type entry struct {
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+	ETag        string `json:"etag"`
+}
+
+// Cache the successful public GET responses, answer the conditional requests,
+// and purge the tags changed by the other methods
+func (c *Cache) Middleware(config Config) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodGet {
+			if err := ctx.Next(); err != nil {
+				return err
+			}
+
+			if ctx.Response().StatusCode() < fiber.StatusBadRequest {
+				if tags := config.PurgedTags(ctx); len(tags) > 0 {
+					c.Purge(tags...)
+				}
+			}
+			return nil
+		}
+
+		tags := config.Tags(ctx)
+		if len(tags) == 0 || ctx.Get(fiber.HeaderAuthorization) != "" {
+			return ctx.Next()
+		}
+
+		times, err := c.store.TagTimes(tags)
+		if err != nil {
+			log.Printf("Couldn't read the cache tags: %s", err)
+			return ctx.Next()
+		}
+
+		// Responses are stored under the purge times of their tags, a purge changes the key
+		lastModified := time.Time{}
+		key := strings.Builder{}
+		for _, t := range times {
+			if t.After(lastModified) {
+				lastModified = t
+			}
+			key.WriteString(fmt.Sprintf("%d:", t.UnixNano()))
+		}
+		key.WriteString(ctx.OriginalURL())
+
+		data, err := c.store.Get(key.String())
+		if err != nil {
+			log.Printf("Couldn't read the cache: %s", err)
+		}
+		if data != nil {
+			var cached entry
+			if err := json.Unmarshal(data, &cached); err == nil {
+				ctx.Set("X-Cache", "HIT")
+				return send(ctx, cached, lastModified)
+			}
+		}
+
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+		if ctx.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := ctx.Response().Body()
+		hash := sha256.Sum256(body)
+		fresh := entry{
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        utils.CopyBytes(body),
+			// Weak, the compression changes the bytes
+			ETag: fmt.Sprintf("W/\"%s\"", hex.EncodeToString(hash[:16])),
+		}
+
+		if data, err := json.Marshal(fresh); err == nil {
+			if err := c.store.Set(key.String(), data, c.ttl); err != nil {
+				log.Printf("Couldn't write the cache: %s", err)
+			}
+		}
+
+		ctx.Set("X-Cache", "MISS")
+		return send(ctx, fresh, lastModified)
+	}
+}
+
+func send(ctx *fiber.Ctx, e entry, lastModified time.Time) error {
+	ctx.Set(fiber.HeaderETag, e.ETag)
+	ctx.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	// Clients can keep the response, but have to check it's still valid
+	ctx.Set(fiber.HeaderCacheControl, "public, no-cache")
+
+	if NotModified(ctx, e.ETag, lastModified) {
+		ctx.Response().ResetBody()
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, e.ContentType)
+	return ctx.Status(fiber.StatusOK).Send(e.Body)
+}
+
+// Whether the client already has the response, by its ETag or else by its date
+func NotModified(ctx *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if noneMatch := ctx.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		etag = strings.TrimPrefix(etag, "W/")
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !lastModified.IsZero() && !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,151 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func testApp() *fiber.App {
+	cache := New(NewMemoryStore(10), time.Minute)
+	version := 0
+
+	app := fiber.New()
+	app.Use(cache.Middleware(Config{
+		Tags: func(ctx *fiber.Ctx) []string {
+			if ctx.Path() == "/sources" {
+				return []string{TagSources}
+			}
+			return nil
+		},
+		PurgedTags: func(ctx *fiber.Ctx) []string {
+			return []string{TagSources}
+		},
+	}))
+
+	app.Get("/sources", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(fiber.Map{"version": version})
+	})
+	app.Get("/uncached", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(fiber.Map{"version": version})
+	})
+	app.Post("/sources", func(ctx *fiber.Ctx) error {
+		version++
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/fail", func(ctx *fiber.Ctx) error {
+		version++
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	})
+
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, path string, headers map[string]string) (*http.Response, string) {
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func post(t *testing.T, app *fiber.App, path string) {
+	_, err := app.Test(httptest.NewRequest(fiber.MethodPost, path, nil))
+	require.NoError(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("cache until purged", func(t *testing.T) {
+		app := testApp()
+
+		resp, body := get(t, app, "/sources", nil)
+		require.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		require.Equal(t, `{"version":0}`, body)
+
+		post(t, app, "/fail")
+		resp, body = get(t, app, "/sources", nil)
+		require.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		require.Equal(t, `{"version":0}`, body)
+		require.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+
+		post(t, app, "/sources")
+		resp, body = get(t, app, "/sources", nil)
+		require.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		require.Equal(t, `{"version":2}`, body)
+	})
+
+	t.Run("conditional requests", func(t *testing.T) {
+		app := testApp()
+
+		resp, _ := get(t, app, "/sources", nil)
+		etag := resp.Header.Get(fiber.HeaderETag)
+		lastModified := resp.Header.Get(fiber.HeaderLastModified)
+		require.NotEmpty(t, etag)
+		require.NotEmpty(t, lastModified)
+
+		resp, body := get(t, app, "/sources", map[string]string{fiber.HeaderIfNoneMatch: etag})
+		require.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+		require.Empty(t, body)
+
+		resp, _ = get(t, app, "/sources", map[string]string{fiber.HeaderIfNoneMatch: `"other"`})
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		resp, _ = get(t, app, "/sources", map[string]string{fiber.HeaderIfModifiedSince: lastModified})
+		require.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+
+		// Purge times are in nanoseconds, wait for the next second to compare them with the dates
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+		post(t, app, "/sources")
+		resp, _ = get(t, app, "/sources", map[string]string{fiber.HeaderIfModifiedSince: lastModified})
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		app := testApp()
+
+		resp, _ := get(t, app, "/uncached", nil)
+		require.Empty(t, resp.Header.Get("X-Cache"))
+
+		resp, _ = get(t, app, "/sources", map[string]string{fiber.HeaderAuthorization: "key"})
+		require.Empty(t, resp.Header.Get("X-Cache"))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(2)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Set(fmt.Sprint(i), []byte{byte(i)}, time.Minute))
+	}
+	value, err := store.Get("2")
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, value)
+
+	require.NoError(t, store.Set("expired", []byte{1}, -time.Second))
+	value, err = store.Get("expired")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	times, err := store.TagTimes([]string{TagContents})
+	require.NoError(t, err)
+	again, err := store.TagTimes([]string{TagContents})
+	require.NoError(t, err)
+	require.Equal(t, times, again)
+
+	require.NoError(t, store.Purge(TagContents))
+	purged, err := store.TagTimes([]string{TagContents})
+	require.NoError(t, err)
+	require.True(t, purged[0].After(times[0]))
+}
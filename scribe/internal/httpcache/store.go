@@ -0,0 +1,186 @@
+package httpcache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Where the responses and the purge times of the tags are kept
+type Store interface {
+	// Returns nil when there is nothing for the key
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	// Last purge time of each tag. Tags never purged get the current time
+	TagTimes(tags []string) ([]time.Time, error)
+	// Set the purge time of the tags to now, the responses cached with the
+	// previous times aren't used anymore
+	Purge(tags ...string) error
+}
+
+// Store configured by the CACHE_STORE variable: "memory", the default, or
+// "redis" to share it between the instances
+func StoreFromEnv() (Store, error) {
+	switch os.Getenv("CACHE_STORE") {
+	case "", "memory":
+		return NewMemoryStore(memoryStoreSize), nil
+	case "redis":
+		return NewRedisStore(os.Getenv("REDIS_URL"))
+	}
+
+	return nil, errors.New("unknown CACHE_STORE")
+}
+
+// Number of responses kept in memory
+const memoryStoreSize = 1000
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Cache of a single instance
+type memoryStore struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]memoryEntry
+	tags    map[string]time.Time
+}
+
+func NewMemoryStore(size int) Store {
+	return &memoryStore{size: size, entries: map[string]memoryEntry{}, tags: map[string]time.Time{}}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	return entry.value, nil
+}
+
+func (s *memoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= s.size {
+		// Drop the expired responses, or everything when none has expired yet
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		if len(s.entries) >= s.size {
+			s.entries = map[string]memoryEntry{}
+		}
+	}
+
+	s.entries[key] = memoryEntry{value, now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) TagTimes(tags []string) ([]time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	times := make([]time.Time, len(tags))
+	for i, tag := range tags {
+		if _, ok := s.tags[tag]; !ok {
+			s.tags[tag] = time.Now()
+		}
+		times[i] = s.tags[tag]
+	}
+	return times, nil
+}
+
+func (s *memoryStore) Purge(tags ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for _, tag := range tags {
+		s.tags[tag] = now
+	}
+	return nil
+}
+
+// Cache shared by every instance using the same Redis
+type redisStore struct {
+	client *redis.Client
+}
+
+const (
+	redisKeyPrefix = "scribe:cache:"
+	redisTagPrefix = "scribe:cache-tag:"
+)
+
+func NewRedisStore(url string) (Store, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+	return &redisStore{client}, nil
+}
+
+func (s *redisStore) Get(key string) ([]byte, error) {
+	value, err := s.client.Get(context.Background(), redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (s *redisStore) Set(key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(context.Background(), redisKeyPrefix+key, value, ttl).Err()
+}
+
+func (s *redisStore) TagTimes(tags []string) ([]time.Time, error) {
+	ctx := context.Background()
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	pipe := s.client.Pipeline()
+	gets := make([]*redis.StringCmd, len(tags))
+	for i, tag := range tags {
+		pipe.SetNX(ctx, redisTagPrefix+tag, now, 0)
+		gets[i] = pipe.Get(ctx, redisTagPrefix+tag)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, len(tags))
+	for i, get := range gets {
+		nanos, err := get.Int64()
+		if err != nil {
+			return nil, err
+		}
+		times[i] = time.Unix(0, nanos)
+	}
+	return times, nil
+}
+
+func (s *redisStore) Purge(tags ...string) error {
+	ctx := context.Background()
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	pipe := s.client.Pipeline()
+	for _, tag := range tags {
+		pipe.Set(ctx, redisTagPrefix+tag, now, 0)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,125 @@
+// Resize the mirrored images into fixed size derivatives
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+
+	// Decoders for the formats we get from the sources
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/buckket/go-blurhash"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Widths of the derivatives, images are never upscaled
+var Sizes = []Size{
+	{"small", 320},
+	{"medium", 640},
+	{"large", 1280},
+}
+
+var Formats = map[string]string{
+	"jpg":  "image/jpeg",
+	"webp": "image/webp",
+}
+
+type encoder func(w io.Writer, img image.Image) error
+
+// Encoders of the derivatives, by format. WebP is only encoded in cgo builds
+var encoders = map[string]encoder{
+	"jpg": func(w io.Writer, img image.Image) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	},
+}
+
+const quality = 80
+
+// Images bigger than that are not worth decoding
+const maxPixels = 50_000_000
+
+var ErrTooLarge = errors.New("image too large")
+
+type Derivative struct {
+	// <size>.<format>, like medium.webp
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type Result struct {
+	Width       int
+	Height      int
+	BlurHash    string
+	Derivatives []Derivative
+}
+
+// Process decodes the image and builds the derivatives of every size in every format it can encode
+func Process(data []byte) (*Result, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	result := &Result{Width: bounds.Dx(), Height: bounds.Dy()}
+
+	for _, size := range Sizes {
+		resized := resize(src, size.Width)
+
+		if size.Name == Sizes[0].Name {
+			// The hash is only a placeholder, no need to compute it on the full image
+			if result.BlurHash, err = blurhash.Encode(4, 3, resized); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, format := range []string{"jpg", "webp"} {
+			encode, ok := encoders[format]
+			if !ok {
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := encode(&buf, resized); err != nil {
+				return nil, err
+			}
+			result.Derivatives = append(result.Derivatives, Derivative{size.Name + "." + format, Formats[format], buf.Bytes()})
+		}
+	}
+
+	return result, nil
+}
+
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,40 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 400; y++ {
+			src.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var data bytes.Buffer
+	require.NoError(t, png.Encode(&data, src))
+
+	result, err := Process(data.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, 800, result.Width)
+	require.Equal(t, 400, result.Height)
+	require.NotEmpty(t, result.BlurHash)
+
+	names := []string{}
+	for _, derivative := range result.Derivatives {
+		names = append(names, derivative.Name)
+		require.NotEmpty(t, derivative.Data)
+	}
+	require.Subset(t, names, []string{"small.jpg", "medium.jpg", "large.jpg"})
+	if _, ok := encoders["webp"]; ok {
+		require.Subset(t, names, []string{"small.webp", "medium.webp", "large.webp"})
+	} else {
+		require.NotContains(t, names, "small.webp")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build cgo
+
+package images
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// The only WebP encoder is libwebp, through cgo
+func init() {
+	encoders["webp"] = func(w io.Writer, img image.Image) error {
+		return webp.Encode(w, img, &webp.Options{Quality: quality})
+	}
+}
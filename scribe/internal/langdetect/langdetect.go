@@ -0,0 +1,28 @@
+// Offline language detection of the contents, based on trigrams
+package langdetect
+
+import (
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// Below that the detected language is dropped and the source one is used
+const MinConfidence = whatlanggo.ReliableConfidenceThreshold
+
+// Detect returns the ISO 639-1 code of the language of the text along with
+// the confidence of the detection, between 0 and 1. The code is empty when
+// the detection isn't reliable enough.
+func Detect(text string) (string, float64) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", 0
+	}
+
+	info := whatlanggo.Detect(text)
+	if info.Confidence < MinConfidence {
+		return "", info.Confidence
+	}
+
+	return info.Lang.Iso6391(), info.Confidence
+}
@@ -0,0 +1,40 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Run("reliable text", func(t *testing.T) {
+		for text, lang := range map[string]string{
+			"The skater landed an impressive kickflip down the handrail at the plaza last night, the crowd went wild.": "en",
+			"Le skateur a réussi un kickflip impressionnant sur le rail de la place de la République hier soir.":       "fr",
+			"El patinador logró un kickflip impresionante en la barandilla de la plaza anoche.":                        "es",
+		} {
+			code, confidence := Detect(text)
+			require.Equal(t, lang, code, text)
+			require.GreaterOrEqual(t, confidence, MinConfidence)
+		}
+	})
+
+	t.Run("empty text", func(t *testing.T) {
+		code, confidence := Detect("  \n ")
+		require.Empty(t, code)
+		require.Zero(t, confidence)
+	})
+
+	t.Run("short text is unreliable", func(t *testing.T) {
+		for _, text := range []string{"Kickflip", "Tony Hawk lands the 900"} {
+			code, confidence := Detect(text)
+			require.Empty(t, code, text)
+			require.Less(t, confidence, MinConfidence)
+		}
+	})
+
+	t.Run("mixed text is unreliable", func(t *testing.T) {
+		code, _ := Detect("Der Skater landete gestern Abend einen beeindruckenden Kickflip am Geländer. The skater landed an impressive kickflip down the handrail.")
+		require.Empty(t, code)
+	})
+}
@@ -0,0 +1,241 @@
+// Read the metadata of a page from its Open Graph and Twitter card tags and
+// its JSON-LD, to fill what the feeds don't give us
+package metadata
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type Metadata struct {
+	Description  string
+	ImageURL     string
+	Author       string
+	CanonicalURL string
+}
+
+// JSON-LD types describing an article
+var articleTypes = map[string]bool{
+	"Article":              true,
+	"NewsArticle":          true,
+	"BlogPosting":          true,
+	"Report":               true,
+	"ScholarlyArticle":     true,
+	"SocialMediaPosting":   true,
+	"TechArticle":          true,
+	"AnalysisNewsArticle":  true,
+	"OpinionNewsArticle":   true,
+	"ReportageNewsArticle": true,
+	"ReviewNewsArticle":    true,
+}
+
+// Parse reads the metadata of the page. Open Graph comes first, then Twitter
+// cards, JSON-LD and the plain HTML tags. URLs are made absolute and only
+// http(s) ones are kept.
+func Parse(r io.Reader, pageURL string) (*Metadata, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	base, _ := url.Parse(pageURL)
+	ld := linkedData(doc)
+
+	meta := &Metadata{
+		Description: first(
+			property(doc, "og:description"),
+			name(doc, "twitter:description"),
+			ld.description,
+			name(doc, "description"),
+		),
+		ImageURL: first(
+			absolute(base, property(doc, "og:image:secure_url")),
+			absolute(base, property(doc, "og:image:url")),
+			absolute(base, property(doc, "og:image")),
+			absolute(base, name(doc, "twitter:image")),
+			absolute(base, name(doc, "twitter:image:src")),
+			absolute(base, ld.image),
+		),
+		Author: first(
+			ld.author,
+			name(doc, "author"),
+			notURL(property(doc, "article:author")),
+			notURL(name(doc, "article:author")),
+		),
+		CanonicalURL: first(
+			absolute(base, attr(doc.Find(`link[rel="canonical"]`), "href")),
+			absolute(base, property(doc, "og:url")),
+			absolute(base, ld.url),
+		),
+	}
+
+	return meta, nil
+}
+
+func attr(s *goquery.Selection, name string) string {
+	value, _ := s.First().Attr(name)
+	return strings.TrimSpace(value)
+}
+
+// Content of a <meta property="..."> tag. Some sites use name instead of property
+func property(doc *goquery.Document, property string) string {
+	if value := attr(doc.Find(`meta[property="`+property+`"]`), "content"); value != "" {
+		return value
+	}
+	return attr(doc.Find(`meta[name="`+property+`"]`), "content")
+}
+
+// Content of a <meta name="..."> tag. Some sites use property instead of name
+func name(doc *goquery.Document, name string) string {
+	if value := attr(doc.Find(`meta[name="`+name+`"]`), "content"); value != "" {
+		return value
+	}
+	return attr(doc.Find(`meta[property="`+name+`"]`), "content")
+}
+
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func absolute(base *url.URL, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	return u.String()
+}
+
+// Article authors are often a link to their page, not a name
+func notURL(value string) string {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return ""
+	}
+	return value
+}
+
+type article struct {
+	description string
+	image       string
+	author      string
+	url         string
+}
+
+// Fields of the first article found in the JSON-LD scripts
+func linkedData(doc *goquery.Document) article {
+	found := article{}
+
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+
+		node := findArticle(data)
+		if node == nil {
+			return true
+		}
+
+		found = article{
+			description: text(node["description"]),
+			image:       link(node["image"]),
+			author:      names(node["author"]),
+			url:         first(link(node["mainEntityOfPage"]), text(node["url"])),
+		}
+		return false
+	})
+
+	return found
+}
+
+// Look for an article in the node, the lists and the @graph it contains
+func findArticle(data interface{}) map[string]interface{} {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if node := findArticle(item); node != nil {
+				return node
+			}
+		}
+	case map[string]interface{}:
+		if isArticle(v["@type"]) {
+			return v
+		}
+		return findArticle(v["@graph"])
+	}
+	return nil
+}
+
+func isArticle(t interface{}) bool {
+	switch v := t.(type) {
+	case string:
+		return articleTypes[v]
+	case []interface{}:
+		for _, item := range v {
+			if isArticle(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func text(value interface{}) string {
+	s, _ := value.(string)
+	return strings.TrimSpace(s)
+}
+
+// URL of an image or a page, given as a string, an object or a list of them
+func link(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		for _, item := range v {
+			if u := link(item); u != "" {
+				return u
+			}
+		}
+	case map[string]interface{}:
+		return first(text(v["url"]), text(v["@id"]), text(v["contentUrl"]))
+	}
+	return ""
+}
+
+// Names of the authors, given as a string, an object or a list of them
+func names(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return notURL(strings.TrimSpace(v))
+	case []interface{}:
+		all := []string{}
+		for _, item := range v {
+			if n := names(item); n != "" {
+				all = append(all, n)
+			}
+		}
+		return strings.Join(all, ", ")
+	case map[string]interface{}:
+		return text(v["name"])
+	}
+	return ""
+}
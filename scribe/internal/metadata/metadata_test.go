@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parseFixture(t *testing.T, name string, pageURL string) *Metadata {
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	meta, err := Parse(f, pageURL)
+	require.NoError(t, err)
+	return meta
+}
+
+func TestParse(t *testing.T) {
+	t.Run("open graph", func(t *testing.T) {
+		meta := parseFixture(t, "opengraph.html", "https://skate.example.com/tricks/kickflip-crooked?ref=feed")
+
+		require.Equal(t, "How to learn the kickflip to frontside crooked grind on a ledge.", meta.Description)
+		require.Equal(t, "https://skate.example.com/images/kickflip-crooked.jpg", meta.ImageURL)
+		require.Equal(t, "Jane Doe", meta.Author)
+		require.Equal(t, "https://skate.example.com/tricks/kickflip-crooked", meta.CanonicalURL)
+	})
+
+	t.Run("twitter card", func(t *testing.T) {
+		meta := parseFixture(t, "twitter.html", "https://shop.example.com/news/new-shoe-drop")
+
+		require.Equal(t, "The new pro model is out this week.", meta.Description)
+		require.Equal(t, "https://cdn.example.com/shoe.jpg", meta.ImageURL)
+		require.Equal(t, "", meta.Author)
+		require.Equal(t, "https://shop.example.com/news/new-shoe-drop?utm_source=feed", meta.CanonicalURL)
+	})
+
+	t.Run("json-ld", func(t *testing.T) {
+		meta := parseFixture(t, "jsonld.html", "https://news.example.com/contest-results")
+
+		require.Equal(t, "Who won the street final.", meta.Description)
+		require.Equal(t, "https://news.example.com/final.jpg", meta.ImageURL)
+		require.Equal(t, "Jane Doe, John Smith", meta.Author)
+		require.Equal(t, "https://news.example.com/contest-results", meta.CanonicalURL)
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		meta := parseFixture(t, "empty.html", "https://example.com/page")
+
+		require.Equal(t, &Metadata{}, meta)
+	})
+}
@@ -0,0 +1,260 @@
+// Extract the main content of an article page, following the scoring ideas of
+// Arc90's readability: paragraphs give points to their parents, class and id
+// names hint at what is content and what is clutter.
+package readability
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/k3a/html2text"
+	"golang.org/x/net/html"
+)
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveNames      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeNames      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// Elements never part of the content
+const removedElements = "script, style, noscript, iframe, form, button, input, select, textarea, nav, aside, svg, canvas, object, embed, link, meta"
+
+// Attributes kept on the extracted elements
+var keptAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"alt":    true,
+	"title":  true,
+	"width":  true,
+	"height": true,
+}
+
+var ErrNoContent = errors.New("no content found")
+
+// Minimum length of the extracted text to consider it an article
+const minTextLength = 250
+
+type candidate struct {
+	selection *goquery.Selection
+	score     float64
+}
+
+// Extract returns the main content of the page as HTML, with only a few
+// attributes left and links made absolute, along with its plain text.
+func Extract(r io.Reader, pageURL string) (string, string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	base, _ := url.Parse(pageURL)
+
+	doc.Find(removedElements).Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "body" || goquery.NodeName(s) == "html" {
+			return
+		}
+
+		names := className(s)
+		if unlikelyCandidates.MatchString(names) && !maybeCandidate.MatchString(names) {
+			s.Remove()
+		}
+	})
+
+	top := topCandidate(doc)
+	if top == nil {
+		return "", "", ErrNoContent
+	}
+
+	article := collect(top)
+	clean(article, base)
+
+	content, err := article.Html()
+	if err != nil {
+		return "", "", err
+	}
+
+	text := strings.TrimSpace(html2text.HTML2Text(content))
+	if len(text) < minTextLength {
+		return "", "", ErrNoContent
+	}
+
+	return strings.TrimSpace(content), text, nil
+}
+
+func className(s *goquery.Selection) string {
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	return class + " " + id
+}
+
+func classWeight(s *goquery.Selection) float64 {
+	weight := 0.0
+	names := className(s)
+
+	if negativeNames.MatchString(names) {
+		weight -= 25
+	}
+	if positiveNames.MatchString(names) {
+		weight += 25
+	}
+
+	return weight
+}
+
+func initialScore(s *goquery.Selection) float64 {
+	score := classWeight(s)
+
+	switch goquery.NodeName(s) {
+	case "article":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	return score
+}
+
+// Ratio of the text that is inside links
+func linkDensity(s *goquery.Selection) float64 {
+	textLength := len(strings.TrimSpace(s.Text()))
+	if textLength == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(strings.TrimSpace(a.Text()))
+	})
+
+	return float64(linkLength) / float64(textLength)
+}
+
+func topCandidate(doc *goquery.Document) *candidate {
+	candidates := map[*html.Node]*candidate{}
+
+	doc.Find("p, pre, td").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		// One point for the paragraph, one per comma, one per 100 characters up to 3
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), 3)
+
+		parent := p.Parent()
+		for level := 0; level < 2 && parent.Length() > 0; level++ {
+			node := parent.Get(0)
+			if _, ok := candidates[node]; !ok {
+				candidates[node] = &candidate{selection: parent, score: initialScore(parent)}
+			}
+
+			if level == 0 {
+				candidates[node].score += score
+			} else {
+				candidates[node].score += score / 2
+			}
+
+			parent = parent.Parent()
+		}
+	})
+
+	var top *candidate
+	for _, c := range candidates {
+		// Favor candidates with few links
+		c.score *= 1 - linkDensity(c.selection)
+
+		if top == nil || c.score > top.score {
+			top = c
+		}
+	}
+
+	return top
+}
+
+// Gather the top candidate and its siblings that look related
+func collect(top *candidate) *goquery.Selection {
+	threshold := math.Max(10, top.score*0.2)
+	article := goquery.NewDocumentFromNode(&html.Node{Type: html.ElementNode, Data: "div"}).Selection
+
+	parent := top.selection.Parent()
+	if parent.Length() == 0 {
+		return article.AppendSelection(top.selection)
+	}
+
+	parent.Children().Each(func(_ int, sibling *goquery.Selection) {
+		if sibling.IsSelection(top.selection) {
+			article.AppendSelection(sibling.Clone())
+			return
+		}
+
+		score := classWeight(sibling)
+		if goquery.NodeName(sibling) == "p" {
+			text := strings.TrimSpace(sibling.Text())
+			density := linkDensity(sibling)
+
+			if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.HasSuffix(text, ".")) {
+				article.AppendSelection(sibling.Clone())
+			}
+			return
+		}
+
+		if score >= threshold {
+			article.AppendSelection(sibling.Clone())
+		}
+	})
+
+	return article
+}
+
+// Drop the attributes we don't need, empty elements and make links absolute.
+// Links that aren't http(s) are dropped.
+func clean(article *goquery.Selection, base *url.URL) {
+	article.Find("*").Each(func(_ int, s *goquery.Selection) {
+		node := s.Get(0)
+
+		attributes := []html.Attribute{}
+		for _, attr := range node.Attr {
+			if !keptAttributes[attr.Key] {
+				continue
+			}
+
+			if attr.Key == "href" || attr.Key == "src" {
+				u, err := url.Parse(attr.Val)
+				if err != nil {
+					continue
+				}
+				if base != nil {
+					u = base.ResolveReference(u)
+				}
+				// No javascript: or data: links
+				if u.Scheme != "http" && u.Scheme != "https" {
+					continue
+				}
+				attr.Val = u.String()
+			}
+
+			attributes = append(attributes, attr)
+		}
+		node.Attr = attributes
+	})
+
+	article.Find("div, span, p, section").Each(func(_ int, s *goquery.Selection) {
+		if strings.TrimSpace(s.Text()) == "" && s.Find("img, video, picture").Length() == 0 {
+			s.Remove()
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package readability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func extractFixture(t *testing.T, name string, pageURL string) (string, string, error) {
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	return Extract(f, pageURL)
+}
+
+func TestExtract(t *testing.T) {
+	t.Run("article", func(t *testing.T) {
+		content, text, err := extractFixture(t, "article.html", "https://news.example.com/2022/street-league/paris")
+		require.NoError(t, err)
+
+		require.Contains(t, text, "The Street League Skateboarding tour comes back to Paris")
+		require.Contains(t, text, "Tickets go on sale next week")
+
+		// Clutter left out
+		require.NotContains(t, text, "newsletter")
+		require.NotContains(t, text, "First comment")
+		require.NotContains(t, text, "all rights reserved")
+		require.NotContains(t, content, "<nav")
+		require.NotContains(t, content, "<script")
+		require.NotContains(t, content, `class="empty"`)
+	})
+
+	t.Run("links", func(t *testing.T) {
+		content, _, err := extractFixture(t, "article.html", "https://news.example.com/2022/street-league/paris")
+		require.NoError(t, err)
+
+		require.Contains(t, content, `<a href="https://news.example.com/tags/ledges">ledges</a>`)
+		require.Contains(t, content, `<img src="https://news.example.com/2022/images/course.jpg" alt="The course"/>`)
+		require.Contains(t, content, "<a>Share</a>")
+		require.NotContains(t, content, "javascript:")
+		require.NotContains(t, content, "onclick")
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		_, _, err := extractFixture(t, "short.html", "https://news.example.com/gallery")
+		require.ErrorIs(t, err, ErrNoContent)
+	})
+}
@@ -0,0 +1,105 @@
+// Rule based scoring of how much a content is about skateboarding
+package relevance
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode"
+)
+
+//go:embed dictionary.json
+var defaultDictionary []byte
+
+type Term struct {
+	Phrase string  `json:"phrase" validate:"required"`
+	Weight float64 `json:"weight"` // Negative for terms of other sports
+} // @name RelevanceTerm
+
+// Terms looked for in the contents along with the priors of the sources
+type Dictionary struct {
+	SkateSourcePrior float64 `json:"skateSourcePrior" validate:"gt=0,lt=1"` // Score of a content of a skate source matching no term
+	OtherSourcePrior float64 `json:"otherSourcePrior" validate:"gt=0,lt=1"`
+	TitleWeight      float64 `json:"titleWeight" validate:"gte=0"`   // Weight multiplier of the terms found in the title
+	KeywordWeight    float64 `json:"keywordWeight" validate:"gte=0"` // Weight multiplier of the terms found in the keywords
+	Terms            []Term  `json:"terms" validate:"dive"`
+} // @name RelevanceDictionary
+
+// DefaultDictionary is the dictionary used until another one is configured
+func DefaultDictionary() Dictionary {
+	var dictionary Dictionary
+	if err := json.Unmarshal(defaultDictionary, &dictionary); err != nil {
+		panic(err)
+	}
+	return dictionary
+}
+
+type Input struct {
+	Title       string
+	Summary     string
+	Keywords    []string // Feedly keywords or video tags
+	SkateSource bool
+}
+
+type Scorer struct {
+	dictionary Dictionary
+	terms      []Term // With normalized phrases
+}
+
+func NewScorer(dictionary Dictionary) *Scorer {
+	terms := []Term{}
+	for _, term := range dictionary.Terms {
+		if phrase := normalize(term.Phrase); strings.TrimSpace(phrase) != "" {
+			terms = append(terms, Term{Phrase: phrase, Weight: term.Weight})
+		}
+	}
+
+	return &Scorer{dictionary, terms}
+}
+
+// Score returns the relevance of the content, between 0 and 1. The prior of
+// its source is moved by the weight of every term found, once per field.
+func (s *Scorer) Score(input Input) float64 {
+	prior := s.dictionary.OtherSourcePrior
+	if input.SkateSource {
+		prior = s.dictionary.SkateSourcePrior
+	}
+
+	// Keywords are kept apart so phrases don't match across two of them
+	keywords := ""
+	for _, keyword := range input.Keywords {
+		keywords += normalize(keyword)
+	}
+
+	evidence := s.match(normalize(input.Title))*s.dictionary.TitleWeight +
+		s.match(normalize(input.Summary)) +
+		s.match(keywords)*s.dictionary.KeywordWeight
+
+	score := 1 / (1 + math.Exp(-(logit(prior) + evidence)))
+	return math.Round(score*1000) / 1000
+}
+
+func (s *Scorer) match(text string) float64 {
+	weight := 0.0
+	for _, term := range s.terms {
+		if strings.Contains(text, term.Phrase) {
+			weight += term.Weight
+		}
+	}
+	return weight
+}
+
+func logit(p float64) float64 {
+	p = math.Min(math.Max(p, 0.001), 0.999)
+	return math.Log(p / (1 - p))
+}
+
+// Lower case words separated by single spaces, with a space at both ends so
+// phrases only match whole words
+func normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return " " + strings.Join(words, " ") + " "
+}
@@ -0,0 +1,67 @@
+package relevance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	scorer := NewScorer(Dictionary{
+		SkateSourcePrior: 0.8,
+		OtherSourcePrior: 0.2,
+		TitleWeight:      2,
+		KeywordWeight:    1.5,
+		Terms: []Term{
+			{Phrase: "kickflip", Weight: 2},
+			{Phrase: "Street League", Weight: 1},
+			{Phrase: "snowboard", Weight: -3},
+			{Phrase: "  ", Weight: 5},
+			{Phrase: "!", Weight: 5},
+		},
+	})
+
+	t.Run("priors", func(t *testing.T) {
+		require.Equal(t, 0.8, scorer.Score(Input{Title: "Hello", SkateSource: true}))
+		require.Equal(t, 0.2, scorer.Score(Input{Title: "Hello"}))
+	})
+
+	t.Run("empty phrases never match", func(t *testing.T) {
+		require.Equal(t, 0.2, scorer.Score(Input{}))
+		require.Equal(t, 0.2, scorer.Score(Input{Title: "Wow!"}))
+	})
+
+	t.Run("terms raise the score", func(t *testing.T) {
+		require.Greater(t, scorer.Score(Input{Summary: "A kickflip down the stairs"}), 0.2)
+		require.Less(t, scorer.Score(Input{Summary: "A snowboard trip"}), 0.2)
+	})
+
+	t.Run("title counts more", func(t *testing.T) {
+		require.Greater(t,
+			scorer.Score(Input{Title: "Kickflip"}),
+			scorer.Score(Input{Summary: "Kickflip"}),
+		)
+	})
+
+	t.Run("whole words only", func(t *testing.T) {
+		require.Equal(t, 0.2, scorer.Score(Input{Title: "Kickflips"}))
+		require.Greater(t, scorer.Score(Input{Title: "street-league finals"}), 0.2)
+	})
+
+	t.Run("once per field", func(t *testing.T) {
+		require.Equal(t,
+			scorer.Score(Input{Summary: "kickflip"}),
+			scorer.Score(Input{Summary: "kickflip kickflip kickflip"}),
+		)
+	})
+
+	t.Run("phrases don't span keywords", func(t *testing.T) {
+		require.Equal(t, 0.2, scorer.Score(Input{Keywords: []string{"street", "league"}}))
+		require.Greater(t, scorer.Score(Input{Keywords: []string{"street league"}}), 0.2)
+	})
+
+	t.Run("default dictionary", func(t *testing.T) {
+		scorer := NewScorer(DefaultDictionary())
+		require.Greater(t, scorer.Score(Input{Title: "Skateboarding in Barcelona"}), 0.5)
+	})
+}
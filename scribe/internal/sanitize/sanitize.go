@@ -0,0 +1,238 @@
+// Allowlist based HTML sanitizer for the summaries and bodies coming from feeds
+package sanitize
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Allowed elements with their allowed attributes. Other elements are replaced by their children.
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          {},
+	"blockquote": {"cite"},
+	"br":         {},
+	"code":       {},
+	"dd":         {},
+	"del":        {},
+	"div":        {},
+	"dl":         {},
+	"dt":         {},
+	"em":         {},
+	"figcaption": {},
+	"figure":     {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"img":        {"src", "alt", "title", "width", "height"},
+	"li":         {},
+	"ol":         {},
+	"p":          {},
+	"pre":        {},
+	"q":          {"cite"},
+	"s":          {},
+	"small":      {},
+	"span":       {},
+	"strong":     {},
+	"sub":        {},
+	"sup":        {},
+	"table":      {},
+	"tbody":      {},
+	"td":         {"colspan", "rowspan"},
+	"th":         {"colspan", "rowspan"},
+	"thead":      {},
+	"tr":         {},
+	"u":          {},
+	"ul":         {},
+}
+
+// Elements removed along with everything inside them
+var droppedElements = map[string]bool{
+	"button":   true,
+	"embed":    true,
+	"form":     true,
+	"head":     true,
+	"iframe":   true,
+	"input":    true,
+	"math":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// Attributes holding an URL
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// HTML keeps the allowed elements and attributes of s. Relative URLs are
+// resolved against baseURL, the URL of the page s comes from, or dropped
+// without it. Links get rel="noopener noreferrer", images are served over
+// https and only http(s) and mailto URLs are kept.
+func HTML(s string, baseURL string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+
+	base, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), body)
+	if err != nil {
+		return html.EscapeString(s)
+	}
+
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	sanitizeChildren(body, base)
+
+	var b bytes.Buffer
+	for node := body.FirstChild; node != nil; node = node.NextSibling {
+		if err := html.Render(&b, node); err != nil {
+			return html.EscapeString(s)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func sanitizeChildren(parent *html.Node, base *url.URL) {
+	node := parent.FirstChild
+
+	for node != nil {
+		next := node.NextSibling
+
+		switch node.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			name := strings.ToLower(node.Data)
+			attributes, allowed := allowedElements[name]
+
+			if droppedElements[name] {
+				parent.RemoveChild(node)
+				break
+			}
+
+			sanitizeChildren(node, base)
+
+			if !allowed {
+				// Keep the content of unknown elements
+				for child := node.FirstChild; child != nil; child = node.FirstChild {
+					node.RemoveChild(child)
+					parent.InsertBefore(child, node)
+				}
+				parent.RemoveChild(node)
+				break
+			}
+
+			node.Attr = sanitizeAttributes(name, node.Attr, attributes, base)
+
+			if name == "img" && !hasAttribute(node, "src") {
+				parent.RemoveChild(node)
+			}
+		default:
+			// Comments, doctypes...
+			parent.RemoveChild(node)
+		}
+
+		node = next
+	}
+}
+
+func sanitizeAttributes(element string, attrs []html.Attribute, allowed []string, base *url.URL) []html.Attribute {
+	sanitized := []html.Attribute{}
+
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !contains(allowed, strings.ToLower(attr.Key)) {
+			continue
+		}
+
+		if urlAttributes[attr.Key] {
+			u, ok := sanitizeURL(attr.Val, element == "img", base)
+			if !ok {
+				continue
+			}
+			attr.Val = u
+		}
+
+		sanitized = append(sanitized, attr)
+	}
+
+	if element == "a" {
+		sanitized = append(sanitized, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	}
+
+	return sanitized
+}
+
+func sanitizeURL(value string, image bool, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+	case "http":
+		if image {
+			u.Scheme = "https"
+		}
+	case "mailto":
+		if image {
+			return "", false
+		}
+	case "":
+		// Protocol relative URLs, the other relative ones can't be resolved without base
+		if u.Host == "" {
+			return "", false
+		}
+		u.Scheme = "https"
+	default:
+		return "", false
+	}
+
+	return u.String(), true
+}
+
+func hasAttribute(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
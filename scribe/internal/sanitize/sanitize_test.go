@@ -0,0 +1,47 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTML(t *testing.T) {
+	const base = "https://example.com/news/article"
+
+	for name, test := range map[string]struct {
+		input    string
+		expected string
+	}{
+		"empty":                 {"  ", ""},
+		"allowed elements":      {`<p>Kickflip <strong>down</strong> the <em>gap</em></p>`, `<p>Kickflip <strong>down</strong> the <em>gap</em></p>`},
+		"script":                {`<p>Hi</p><script>alert(1)</script>`, `<p>Hi</p>`},
+		"style and iframe":      {`<style>p{}</style><iframe src="https://evil.com"></iframe><p>Hi</p>`, `<p>Hi</p>`},
+		"unknown element":       {`<section><p>Hi</p></section>`, `<p>Hi</p>`},
+		"comment":               {`<p>Hi<!-- hidden --></p>`, `<p>Hi</p>`},
+		"event handlers":        {`<p onclick="alert(1)" onmouseover="alert(2)">Hi</p>`, `<p>Hi</p>`},
+		"style attribute":       {`<p style="position:fixed">Hi</p>`, `<p>Hi</p>`},
+		"javascript link":       {`<a href="javascript:alert(1)">Hi</a>`, `<a rel="noopener noreferrer">Hi</a>`},
+		"javascript link case":  {`<a href=" JaVaScRiPt:alert(1)">Hi</a>`, `<a rel="noopener noreferrer">Hi</a>`},
+		"data image":            {`<img src="data:image/png;base64,AAAA">`, ``},
+		"data link":             {`<a href="data:text/html,<script>alert(1)</script>">Hi</a>`, `<a rel="noopener noreferrer">Hi</a>`},
+		"link":                  {`<a href="https://example.com" target="_blank">Hi</a>`, `<a href="https://example.com" rel="noopener noreferrer">Hi</a>`},
+		"mailto link":           {`<a href="mailto:hi@example.com">Hi</a>`, `<a href="mailto:hi@example.com" rel="noopener noreferrer">Hi</a>`},
+		"http image":            {`<img src="http://example.com/a.jpg" alt="A">`, `<img src="https://example.com/a.jpg" alt="A"/>`},
+		"protocol relative":     {`<img src="//cdn.example.com/a.jpg">`, `<img src="https://cdn.example.com/a.jpg"/>`},
+		"relative link":         {`<a href="../videos/1">Hi</a>`, `<a href="https://example.com/videos/1" rel="noopener noreferrer">Hi</a>`},
+		"root relative image":   {`<img src="/images/a.jpg">`, `<img src="https://example.com/images/a.jpg"/>`},
+		"relative cite":         {`<blockquote cite="quote">Hi</blockquote>`, `<blockquote cite="https://example.com/news/quote">Hi</blockquote>`},
+		"text is escaped":       {`a < b & c`, `a &lt; b &amp; c`},
+		"nested dropped inside": {`<div><form><input value="x"></form>Hi</div>`, `<div>Hi</div>`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, HTML(test.input, base))
+		})
+	}
+
+	t.Run("relative URLs without base", func(t *testing.T) {
+		require.Equal(t, `<a rel="noopener noreferrer">Hi</a>`, HTML(`<a href="/videos/1">Hi</a>`, ""))
+		require.Equal(t, ``, HTML(`<img src="a.jpg">`, "not a url"))
+	})
+}
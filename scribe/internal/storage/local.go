@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Keep the objects as files in a directory
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		return nil, errors.New("missing IMAGE_STORAGE_PATH")
+	}
+
+	if err := os.MkdirAll(root, 0750); err != nil {
+		return nil, err
+	}
+
+	return &Local{root}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	path := filepath.Join(l.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(l.root)+string(os.PathSeparator)) {
+		return "", errors.New("invalid key")
+	}
+	return path, nil
+}
+
+func (l *Local) Put(key string, data []byte, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+func (l *Local) Get(key string) ([]byte, string, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- Path is checked to be inside the root
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, mime.TypeByExtension(filepath.Ext(path)), nil
+}
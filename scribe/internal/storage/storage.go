@@ -0,0 +1,37 @@
+// Where the mirrored images are kept
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	// Returns ErrNotFound when there is no object for the key
+	Get(key string) ([]byte, string, error)
+}
+
+// Storage configured by the IMAGE_STORAGE variable: "local" or "s3".
+// Returns nil when it isn't set.
+func FromEnv() (Storage, error) {
+	switch os.Getenv("IMAGE_STORAGE") {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocal(os.Getenv("IMAGE_STORAGE_PATH"))
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    os.Getenv("S3_INSECURE") != "true",
+		})
+	}
+
+	return nil, errors.New("unknown IMAGE_STORAGE")
+}
@@ -0,0 +1,123 @@
+package syndication
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2022, 7, 14, 18, 30, 0, 0, time.UTC)
+	return &Feed{
+		Title:   "Scribe",
+		Link:    "https://scribe.example.com",
+		FeedURL: "https://scribe.example.com/feeds/contents.rss",
+		Updated: published,
+		Items: []Item{
+			{
+				ID:        "urn:uuid:8f6c1a7e-4b7f-4a8e-9d36-2f1b7c0e5d11",
+				Title:     "Tony Hawk & friends",
+				Link:      "https://www.thrashermagazine.com/articles/tony-hawk",
+				Summary:   "A 900 <again>",
+				Author:    "Thrasher",
+				Published: published,
+				Image:     &Enclosure{URL: "https://scribe.example.com/images/abc/large.jpg", Type: "image/jpeg"},
+			},
+			{
+				ID:        "urn:uuid:0c1d8d43-7b1a-4d9f-8a43-5d0f0a1f2b3c",
+				Title:     "Part",
+				Link:      "https://www.youtube.com/watch?v=abc",
+				Published: published.Add(-time.Hour),
+			},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := testFeed().RSS()
+	require.NoError(t, err)
+
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+				GUID        struct {
+					Value       string `xml:",chardata"`
+					IsPermaLink string `xml:"isPermaLink,attr"`
+				} `xml:"guid"`
+				PubDate   string `xml:"pubDate"`
+				Enclosure *struct {
+					URL    string `xml:"url,attr"`
+					Type   string `xml:"type,attr"`
+					Length string `xml:"length,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+
+	require.Len(t, feed.Channel.Items, 2)
+	item := feed.Channel.Items[0]
+	require.Equal(t, "Tony Hawk & friends", item.Title)
+	require.Equal(t, "A 900 <again>", item.Description)
+	require.Equal(t, "urn:uuid:8f6c1a7e-4b7f-4a8e-9d36-2f1b7c0e5d11", item.GUID.Value)
+	require.Equal(t, "false", item.GUID.IsPermaLink)
+	require.Equal(t, "Thu, 14 Jul 2022 18:30:00 +0000", item.PubDate)
+	require.NotNil(t, item.Enclosure)
+	require.Equal(t, "image/jpeg", item.Enclosure.Type)
+	require.Equal(t, "0", item.Enclosure.Length)
+
+	require.Nil(t, feed.Channel.Items[1].Enclosure)
+}
+
+func TestAtom(t *testing.T) {
+	data, err := testFeed().Atom()
+	require.NoError(t, err)
+
+	var feed struct {
+		Author struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Entries []struct {
+			ID     string `xml:"id"`
+			Author *struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Links []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+
+	require.Equal(t, "Scribe", feed.Author.Name)
+	require.Len(t, feed.Entries, 2)
+	require.Equal(t, "urn:uuid:8f6c1a7e-4b7f-4a8e-9d36-2f1b7c0e5d11", feed.Entries[0].ID)
+	require.Equal(t, "Thrasher", feed.Entries[0].Author.Name)
+	require.Len(t, feed.Entries[0].Links, 2)
+	require.Equal(t, "enclosure", feed.Entries[0].Links[1].Rel)
+	require.Nil(t, feed.Entries[1].Author)
+}
+
+func TestJSON(t *testing.T) {
+	data, err := testFeed().JSON()
+	require.NoError(t, err)
+
+	var feed map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &feed))
+
+	require.Equal(t, "https://jsonfeed.org/version/1.1", feed["version"])
+	items := feed["items"].([]interface{})
+	require.Len(t, items, 2)
+
+	item := items[0].(map[string]interface{})
+	require.Equal(t, "urn:uuid:8f6c1a7e-4b7f-4a8e-9d36-2f1b7c0e5d11", item["id"])
+	require.Equal(t, "https://scribe.example.com/images/abc/large.jpg", item["image"])
+	require.Equal(t, "2022-07-14T18:30:00Z", item["date_published"])
+	require.NotContains(t, items[1], "attachments")
+}
@@ -10,11 +10,13 @@ import (
 	"github.com/skatekrak/scribe/clients/vimeo"
 	"github.com/skatekrak/scribe/clients/youtube"
 	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/internal/httpcache"
+	"github.com/skatekrak/scribe/internal/storage"
 	"github.com/skatekrak/scribe/services"
 	"gorm.io/gorm"
 )
 
-func Setup(db *gorm.DB) {
+func Setup(db *gorm.DB, storage storage.Storage, cache *httpcache.Cache) {
 	s := gocron.NewScheduler(time.UTC)
 
 	if db == nil {
@@ -22,18 +24,39 @@ func Setup(db *gorm.DB) {
 	}
 
 	// At midnight every day
-	if _, err := s.Cron("0 0 * * *").Do(refreshFeedly(db)); err != nil {
+	if _, err := s.Cron("0 0 * * *").Do(refreshFeedly(db, cache)); err != nil {
 		log.Fatalf("Cannot start refreshFeedly job: %s", err.Error())
 	}
-	if _, err := s.Cron("0 0 * * *").Do(refreshVideos(db)); err != nil {
+	if _, err := s.Cron("0 0 * * *").Do(refreshVideos(db, cache)); err != nil {
 		log.Fatalf("Cannot start refreshVideos job: %s", err.Error())
 	}
 
+	// Every 6 hours
+	if _, err := s.Cron("0 */6 * * *").Do(refreshVideoStats(db, cache)); err != nil {
+		log.Fatalf("Cannot start refreshVideoStats job: %s", err.Error())
+	}
+
+	// Every 15 minutes
+	if _, err := s.Cron("*/15 * * * *").Do(refreshLiveVideos(db, cache)); err != nil {
+		log.Fatalf("Cannot start refreshLiveVideos job: %s", err.Error())
+	}
+
+	// Every minute
+	// A run still sending the batches it claimed isn't overlapped by the next one
+	if _, err := s.Cron("* * * * *").SingletonMode().Do(deliverWebhooks(db)); err != nil {
+		log.Fatalf("Cannot start deliverWebhooks job: %s", err.Error())
+	}
+
+	// Every 5 minutes
+	if _, err := s.Cron("*/5 * * * *").SingletonMode().Do(mirrorImages(db, storage, cache)); err != nil {
+		log.Fatalf("Cannot start mirrorImages job: %s", err.Error())
+	}
+
 	s.StartAsync()
 	log.Println("scheduler started")
 }
 
-func refreshFeedly(db *gorm.DB) func() {
+func refreshFeedly(db *gorm.DB, cache *httpcache.Cache) func() {
 	return func() {
 		feedlyCategoryID := os.Getenv("FEEDLY_FETCH_CATEGORY_ID")
 
@@ -42,21 +65,23 @@ func refreshFeedly(db *gorm.DB) func() {
 
 		refreshService := services.NewRefreshService(db, fetcher, feedlyCategoryID)
 
-		if _, err := refreshService.RefreshFeedlySource(); err != nil {
+		if _, err := refreshService.RefreshFeedlySource(false); err != nil {
 			log.Printf("Error refreshing feedly sources: %s", err.Error())
 		} else {
 			log.Println("Feedly sources refreshed")
 		}
 
-		if _, err := refreshService.RefreshByTypes([]string{"rss"}); err != nil {
+		if _, err := refreshService.RefreshByTypes([]string{"rss"}, false); err != nil {
 			log.Printf("Error fetching feedly contents: %s", err.Error())
 		} else {
 			log.Println("Feedly contents refreshed")
 		}
+
+		cache.Purge(httpcache.TagContents, httpcache.TagSources)
 	}
 }
 
-func refreshVideos(db *gorm.DB) func() {
+func refreshVideos(db *gorm.DB, cache *httpcache.Cache) func() {
 	return func() {
 		youtubeClient := youtube.New(os.Getenv("YOUTUBE_API_KEY"))
 		vimeoClient := vimeo.New(os.Getenv("VIMEO_API_KEY"))
@@ -65,10 +90,103 @@ func refreshVideos(db *gorm.DB) func() {
 
 		refreshService := services.NewRefreshService(db, fetcher, "")
 
-		if _, err := refreshService.RefreshByTypes([]string{"vimeo", "youtube"}); err != nil {
+		if _, err := refreshService.RefreshByTypes([]string{"vimeo", "youtube"}, false); err != nil {
 			log.Printf("Error refreshing videos: %s", err.Error())
 		} else {
 			log.Println("Videos refreshed")
 		}
+
+		cache.Purge(httpcache.TagContents, httpcache.TagSources)
+	}
+}
+
+// Statistics of the videos published in the last 30 days
+func refreshVideoStats(db *gorm.DB, cache *httpcache.Cache) func() {
+	return func() {
+		youtubeClient := youtube.New(os.Getenv("YOUTUBE_API_KEY"))
+		vimeoClient := vimeo.New(os.Getenv("VIMEO_API_KEY"))
+
+		fetcher := fetchers.New(vimeoClient, youtubeClient, nil)
+
+		refreshService := services.NewRefreshService(db, fetcher, "")
+
+		if updated, err := refreshService.RefreshVideoStats(30 * 24 * time.Hour); err != nil {
+			log.Printf("Error refreshing video stats: %s", err.Error())
+		} else {
+			log.Printf("Stats of %d videos refreshed", updated)
+			cache.Purge(httpcache.TagContents)
+		}
+	}
+}
+
+// Upcoming and ongoing live streams, to know when they start or end
+func refreshLiveVideos(db *gorm.DB, cache *httpcache.Cache) func() {
+	return func() {
+		youtubeClient := youtube.New(os.Getenv("YOUTUBE_API_KEY"))
+		vimeoClient := vimeo.New(os.Getenv("VIMEO_API_KEY"))
+
+		fetcher := fetchers.New(vimeoClient, youtubeClient, nil)
+
+		refreshService := services.NewRefreshService(db, fetcher, "")
+
+		if updated, err := refreshService.RefreshLiveVideos(); err != nil {
+			log.Printf("Error refreshing live videos: %s", err.Error())
+		} else {
+			log.Printf("%d live videos refreshed", updated)
+			cache.Purge(httpcache.TagContents)
+		}
 	}
 }
+
+// Thumbnails and icons of the contents and sources changed lately, left to the
+// job so the refreshes and the API don't wait for the downloads
+func mirrorImages(db *gorm.DB, storage storage.Storage, cache *httpcache.Cache) func() {
+	imageService := services.NewImageService(db, storage)
+
+	return func() {
+		contents, sources, err := imageService.MirrorPending(time.Now().Add(-imageMirrorWindow), imageBatchSize)
+		if err != nil {
+			log.Printf("Error mirroring images: %s", err.Error())
+		}
+
+		// Also after an error, for what was linked before it
+		if contents > 0 {
+			log.Printf("Images of %d contents mirrored", contents)
+			cache.Purge(httpcache.TagContents)
+		}
+		if sources > 0 {
+			log.Printf("Images of %d sources mirrored", sources)
+			cache.Purge(httpcache.TagSources)
+		}
+	}
+}
+
+// Changes looked at by the mirroring. Longer than the retry delay of the failed images
+const imageMirrorWindow = 24 * time.Hour
+
+// Contents or sources mirrored at once
+const imageBatchSize = 100
+
+// Pending webhook deliveries, including the retries that are due
+func deliverWebhooks(db *gorm.DB) func() {
+	webhookService := services.NewWebhookService(db)
+
+	return func() {
+		for {
+			delivered, err := webhookService.DeliverPending(webhookBatchSize)
+			if err != nil {
+				log.Printf("Error delivering webhooks: %s", err.Error())
+				return
+			}
+			if delivered > 0 {
+				log.Printf("%d webhooks delivered", delivered)
+			}
+			if delivered < webhookBatchSize {
+				return
+			}
+		}
+	}
+}
+
+// Deliveries claimed at once by an instance
+const webhookBatchSize = 50
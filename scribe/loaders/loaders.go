@@ -64,3 +64,23 @@ func ContentLoader(s *services.ContentService) fiber.Handler {
 		return ctx.Next()
 	}
 }
+
+const WEBHOOK_LOADER_LOCAL = "webhookId"
+
+func WebhookLoader(s *services.WebhookService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		webhookId := ctx.Params(WEBHOOK_LOADER_LOCAL)
+
+		webhook, err := s.Get(webhookId)
+		if err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "Webhook not found")
+		}
+
+		ctx.Locals(WEBHOOK_LOADER_LOCAL, webhook)
+		return ctx.Next()
+	}
+}
+
+func GetWebhook(ctx *fiber.Ctx) model.Webhook {
+	return ctx.Locals(WEBHOOK_LOADER_LOCAL).(model.Webhook)
+}
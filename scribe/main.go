@@ -4,23 +4,30 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ansrivas/fiberprometheus/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/basicauth"
-	"github.com/gofiber/fiber/v2/middleware/cache"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/fiber/v2/utils"
 	"github.com/gofiber/swagger"
 	"github.com/skatekrak/scribe/api/content"
+	"github.com/skatekrak/scribe/api/feed"
+	"github.com/skatekrak/scribe/api/image"
 	"github.com/skatekrak/scribe/api/lang"
 	"github.com/skatekrak/scribe/api/refresh"
+	"github.com/skatekrak/scribe/api/relevance"
 	"github.com/skatekrak/scribe/api/source"
+	"github.com/skatekrak/scribe/api/sync"
+	"github.com/skatekrak/scribe/api/webhook"
 	_ "github.com/skatekrak/scribe/docs"
+	"github.com/skatekrak/scribe/internal/httpcache"
+	"github.com/skatekrak/scribe/internal/storage"
 	"github.com/skatekrak/scribe/jobs"
 	"github.com/skatekrak/scribe/model"
 	"github.com/skatekrak/scribe/services"
@@ -45,12 +52,31 @@ func main() {
 		log.Fatalf("unable to open database: %s", err)
 	}
 
-	if err = db.AutoMigrate(&model.Lang{}, &model.Source{}, &model.Content{}, &model.Config{}); err != nil {
+	if err = db.AutoMigrate(&model.Lang{}, &model.Image{}, &model.Source{}, &model.Content{}, &model.ContentRevision{}, &model.Config{}, &model.Webhook{}, &model.WebhookDelivery{}); err != nil {
 		log.Fatalf("unable to migrate database: %s", err)
 	}
+	if err = services.MigrateSearch(db); err != nil {
+		log.Fatalf("unable to migrate search: %s", err)
+	}
+	if err = services.MigrateSync(db); err != nil {
+		log.Fatalf("unable to migrate sync: %s", err)
+	}
+	if err = services.MigrateDuplicates(db); err != nil {
+		log.Fatalf("unable to migrate duplicates: %s", err)
+	}
 
 	setupConfig(db)
 
+	imageStorage, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("unable to setup image storage: %s", err)
+	}
+
+	cacheStore, err := httpcache.StoreFromEnv()
+	if err != nil {
+		log.Fatalf("unable to setup cache store: %s", err)
+	}
+
 	app := fiber.New()
 
 	// Setup prometheus for Go Fiber
@@ -65,21 +91,23 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: os.Getenv("CORS_ORIGINS"),
 	}))
-	app.Use(compress.New())
-	app.Use(cache.New(cache.Config{
+	app.Use(compress.New(compress.Config{
 		Next: func(ctx *fiber.Ctx) bool {
-			// Only cache the GET requests
-			return ctx.Method() != "GET"
-		},
-		KeyGenerator: func(ctx *fiber.Ctx) string {
-			return utils.CopyString(ctx.OriginalURL())
+			// Events have to be sent as they come
+			return ctx.Path() == "/contents/stream"
 		},
-		Expiration:   30 * time.Minute,
-		CacheControl: true,
 	}))
-	setupRoutes(db, app)
+	responseCache := httpcache.New(cacheStore, 30*time.Minute)
+	app.Use(responseCache.Middleware(httpcache.Config{
+		Tags:       cacheTags,
+		PurgedTags: purgedCacheTags,
+	}))
+	contentStream := services.NewContentStream(services.NewContentService(db), os.Getenv("POSTGRESQL_ADDON_URI"))
+	contentStream.Start()
+
+	setupRoutes(db, app, imageStorage, contentStream)
 
-	jobs.Setup(db)
+	jobs.Setup(db, imageStorage, responseCache)
 
 	if err := app.Listen(fmt.Sprintf(":%s", os.Getenv("PORT"))); err != nil {
 		log.Fatalln("Error listening")
@@ -95,14 +123,56 @@ func setupConfig(db *gorm.DB) {
 	}
 }
 
-func setupRoutes(db *gorm.DB, app *fiber.App) {
+// Resources shown by a public response. Images are already cached by the
+// clients, feeds answer conditional requests and streams aren't cached
+func cacheTags(ctx *fiber.Ctx) []string {
+	switch {
+	case ctx.Path() == "/contents/stream":
+		return nil
+	case strings.HasPrefix(ctx.Path(), "/contents"):
+		return []string{httpcache.TagContents, httpcache.TagSources}
+	case strings.HasPrefix(ctx.Path(), "/sources"):
+		return []string{httpcache.TagSources, httpcache.TagLangs}
+	case strings.HasPrefix(ctx.Path(), "/langs"):
+		return []string{httpcache.TagLangs}
+	}
+	return nil
+}
+
+// Resources changed by an admin request. Dry runs change nothing
+func purgedCacheTags(ctx *fiber.Ctx) []string {
+	if dryRun, _ := strconv.ParseBool(ctx.Query("dryRun")); dryRun {
+		return nil
+	}
+
+	switch {
+	case ctx.Path() == "/contents/batch" || ctx.Path() == "/sources/batch":
+		return nil
+	case strings.HasPrefix(ctx.Path(), "/contents"):
+		return []string{httpcache.TagContents}
+	case strings.HasPrefix(ctx.Path(), "/sources"):
+		return []string{httpcache.TagSources}
+	case strings.HasPrefix(ctx.Path(), "/langs"):
+		return []string{httpcache.TagLangs}
+	case strings.HasPrefix(ctx.Path(), "/refresh"):
+		return []string{httpcache.TagContents, httpcache.TagSources}
+	}
+	return nil
+}
+
+func setupRoutes(db *gorm.DB, app *fiber.App, imageStorage storage.Storage, contentStream *services.ContentStream) {
 	app.Use(logger.New())
 	app.Use(recover.New())
 
 	lang.Route(app, db)
 	source.Route(app, db)
-	content.Route(app, db)
+	content.Route(app, db, contentStream)
 	refresh.Route(app, db)
+	image.Route(app, db, imageStorage)
+	relevance.Route(app, db)
+	feed.Route(app, db)
+	webhook.Route(app, db)
+	sync.Route(app, db)
 
 	app.Get("/docs/*", swagger.HandlerDefault)
 }
@@ -2,12 +2,85 @@ package model
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// List of names, of fields, tags or events, stored as a JSON array
+type FieldList []string
+
+func (l FieldList) Has(field string) bool {
+	for _, f := range l {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (l FieldList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *FieldList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = FieldList{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return errors.New("unsupported type for FieldList")
+}
+
+func (FieldList) GormDataType() string {
+	return "jsonb"
+}
+
+// Rules a fetched content has to follow to be saved
+type IngestRules struct {
+	IncludeKeywords []string `json:"includeKeywords"` // When set, the title or summary has to contain one of them
+	ExcludeKeywords []string `json:"excludeKeywords"`
+	IncludePatterns []string `json:"includePatterns"` // Regular expressions matched against the title and the summary
+	ExcludePatterns []string `json:"excludePatterns"`
+	MinDuration     int      `json:"minDuration"` // In seconds, for the videos whose duration is known
+	MaxAge          int      `json:"maxAge"`      // In days
+} // @name IngestRules
+
+func (r IngestRules) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	return string(b), err
+}
+
+func (r *IngestRules) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = IngestRules{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return errors.New("unsupported type for IngestRules")
+}
+
+func (IngestRules) GormDataType() string {
+	return "jsonb"
+}
+
 type Model struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time      `json:"createdAt"`
@@ -36,6 +109,8 @@ type Source struct {
 	Title       string     `json:"title"`
 	ShortTitle  string     `json:"shortTitle"`
 	IconURL     string     `json:"iconUrl"`
+	IconImageID *string    `json:"-"`
+	IconImage   *Image     `json:"iconImage"` // Mirrored icon, null until mirrored
 	CoverURL    string     `json:"coverUrl"`
 	Description string     `json:"description"`
 	SkateSource bool       `gorm:"default:true" json:"skateSource"`
@@ -43,35 +118,241 @@ type Source struct {
 	PublishedAt *time.Time `json:"publishedAt"`
 	SourceID    string     `gorm:"unique,index" json:"sourceId"` // Vimeo, Youtube or Feedly ID, depending on the type
 
+	LockedFields        FieldList `gorm:"default:'[]'" json:"lockedFields" swaggertype:"array,string"`        // Source fields kept by the feedly sync
+	LockedContentFields FieldList `gorm:"default:'[]'" json:"lockedContentFields" swaggertype:"array,string"` // Content fields kept by refreshes, for every content of the source
+
+	IngestRules IngestRules `gorm:"default:'{}'" json:"ingestRules"` // Fetched contents not following them aren't saved
+
 	Contents []Content `json:"-"`
 } // @name Source
 
 type Content struct {
-	ID        string         `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time      `json:"createdAt"`
+	ID        string         `gorm:"primaryKey;index:idx_contents_feed,priority:2" json:"id"`
+	CreatedAt time.Time      `gorm:"index" json:"createdAt"` // Order of the contents stream
+	UpdatedAt time.Time      `gorm:"index" json:"updatedAt"` // Only changed when a field the clients show changes, for the sync
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt" swaggertype:"string"`
 
-	SourceID uint   `json:"-"`
+	SourceID uint   `gorm:"index:idx_contents_source_feed,priority:1" json:"-"`
 	Source   Source `json:"source"`
 
-	ContentID    string    `gorm:"uniqueIndex" json:"contentId"` // Youtube or Vimeo ID or Feedly ID
-	PublishedAt  time.Time `json:"publishedAt"`
+	ContentID    string    `gorm:"uniqueIndex" json:"contentId"`                                                                    // Youtube or Vimeo ID or Feedly ID
+	PublishedAt  time.Time `gorm:"index:idx_contents_feed,priority:1;index:idx_contents_source_feed,priority:2" json:"publishedAt"` // Contents feed order, with the ID
 	Title        string    `json:"title"`
-	ContentURL   string    `json:"contentUrl"` // Youtube or Vimeo video url or article URL
+	ContentURL   string    `json:"contentUrl"`   // Youtube or Vimeo video url or article URL
+	CanonicalURL string    `json:"canonicalUrl"` // URL of the article given by its page
 	ThumbnailURL string    `json:"thumbnailUrl"`
 	RawSummary   string    `json:"rawSummary"`
 	Summary      string    `json:"summary"`
 	RawContent   string    `json:"rawContent"`
 	Content      string    `json:"content"`
 	Author       *string   `json:"author"` // For feedly article
-	Type         string    `json:"type"`
+	Type         string    `gorm:"index" json:"type"`
+	SubType      string    `gorm:"index;default:regular" json:"subType"` // regular, short, live, upcoming or premiere
+
+	// Detected from the title and summary, empty when the detection wasn't reliable
+	LangIsoCode    string  `gorm:"index" json:"langIsoCode"`
+	LangConfidence float64 `json:"langConfidence"`
+
+	Relevance *float64 `gorm:"index" json:"relevance"` // How much it's about skateboarding, between 0 and 1. Null when it hasn't been scored
+
+	// Video details and statistics
+	Duration       int        `json:"duration"` // In seconds
+	ViewCount      *int64     `json:"viewCount"`
+	LikeCount      *int64     `json:"likeCount"`
+	Tags           FieldList  `gorm:"default:'[]'" json:"tags" swaggertype:"array,string"`
+	Definition     string     `json:"definition"` // hd or sd
+	StatsUpdatedAt *time.Time `json:"statsUpdatedAt"`
+
+	LockedFields FieldList `gorm:"default:'[]'" json:"lockedFields" swaggertype:"array,string"` // Fields kept by refreshes
+
+	// Duplicate detection
+	NormalizedURL string  `gorm:"index" json:"-"`
+	Simhash       int64   `json:"-"`                          // Of the summary
+	DuplicateOfID *string `gorm:"index" json:"duplicateOfId"` // Canonical content of its group, null for a canonical content
+
+	ThumbnailImageID *string `json:"-"`
+	ThumbnailImage   *Image  `json:"thumbnailImage"` // Mirrored thumbnail, null until mirrored
+
+	Revisions []ContentRevision `gorm:"constraint:OnDelete:CASCADE" json:"-"`
 } // @name Content
 
 func (c *Content) BeforeCreate(tx *gorm.DB) (err error) {
-	c.ID = uuid.NewString()
+	// Duplicate detection gives an ID to the new contents before they're saved
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
 	return
 }
 
+// What a feed shows of a source, along with its contents
+type CompactSource struct {
+	ID          uint   `json:"id"`
+	SourceType  string `json:"sourceType"`
+	Title       string `json:"title"`
+	ShortTitle  string `json:"shortTitle"`
+	IconURL     string `json:"iconUrl"`
+	IconImage   *Image `json:"iconImage"`
+	WebsiteURL  string `json:"websiteUrl"`
+	SkateSource bool   `json:"skateSource"`
+} // @name CompactSource
+
+func (s *Source) Compact() CompactSource {
+	return CompactSource{
+		ID:          s.ID,
+		SourceType:  s.SourceType,
+		Title:       s.Title,
+		ShortTitle:  s.ShortTitle,
+		IconURL:     s.IconURL,
+		IconImage:   s.IconImage,
+		WebsiteURL:  s.WebsiteURL,
+		SkateSource: s.SkateSource,
+	}
+}
+
+// What a feed shows of a content, without its body and details
+type ContentCard struct {
+	ID             string        `json:"id"`
+	ContentID      string        `json:"contentId"`
+	PublishedAt    time.Time     `json:"publishedAt"`
+	Title          string        `json:"title"`
+	ContentURL     string        `json:"contentUrl"`
+	ThumbnailURL   string        `json:"thumbnailUrl"`
+	ThumbnailImage *Image        `json:"thumbnailImage"`
+	Summary        string        `json:"summary"`
+	Author         *string       `json:"author"`
+	Type           string        `json:"type"`
+	SubType        string        `json:"subType"`
+	LangIsoCode    string        `json:"langIsoCode"`
+	Relevance      *float64      `json:"relevance"`
+	Duration       int           `json:"duration"`
+	ViewCount      *int64        `json:"viewCount"`
+	LikeCount      *int64        `json:"likeCount"`
+	DuplicateOfID  *string       `json:"duplicateOfId"`
+	Source         CompactSource `json:"source"`
+} // @name ContentCard
+
+func (c *Content) Card() ContentCard {
+	return ContentCard{
+		ID:             c.ID,
+		ContentID:      c.ContentID,
+		PublishedAt:    c.PublishedAt,
+		Title:          c.Title,
+		ContentURL:     c.ContentURL,
+		ThumbnailURL:   c.ThumbnailURL,
+		ThumbnailImage: c.ThumbnailImage,
+		Summary:        c.Summary,
+		Author:         c.Author,
+		Type:           c.Type,
+		SubType:        c.SubType,
+		LangIsoCode:    c.LangIsoCode,
+		Relevance:      c.Relevance,
+		Duration:       c.Duration,
+		ViewCount:      c.ViewCount,
+		LikeCount:      c.LikeCount,
+		DuplicateOfID:  c.DuplicateOfID,
+		Source:         c.Source.Compact(),
+	}
+}
+
+// What caused a content field to change
+const (
+	RevisionCauseRefresh = "refresh"
+	RevisionCauseForce   = "force"
+	RevisionCauseManual  = "manual"
+	RevisionCauseRevert  = "revert"
+)
+
+// A change of one stored field of a content
+type ContentRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+	ContentID string    `gorm:"index" json:"contentId"` // Scribe ID of the content
+	Field     string    `json:"field"`
+	OldValue  string    `json:"oldValue"`
+	NewValue  string    `json:"newValue"`
+	Cause     string    `json:"cause"` // refresh, force, manual or revert
+} // @name ContentRevision
+
+// An image mirrored from a source, stored along with its derivatives
+type Image struct {
+	ID        string    `gorm:"primaryKey" json:"id"` // Hash of the source URL
+	CreatedAt time.Time `json:"createdAt"`
+	SourceURL string    `json:"sourceUrl"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	BlurHash  string    `json:"blurHash"`
+	// Available derivatives, like medium.webp, served at /images/:id/:derivative
+	Derivatives FieldList `gorm:"default:'[]'" json:"derivatives" swaggertype:"array,string"`
+} // @name Image
+
+// Contents and sources a webhook is sent for, empty ones are ignored
+type WebhookFilters struct {
+	SourceTypes  []string `json:"sourceTypes"`
+	Sources      []int    `json:"sources"`
+	Types        []string `json:"types"` // video or article
+	Langs        []string `json:"langs"`
+	SkateOnly    bool     `json:"skateOnly"`
+	MinRelevance *float64 `json:"minRelevance"`
+} // @name WebhookFilters
+
+func (f WebhookFilters) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+func (f *WebhookFilters) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = WebhookFilters{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return errors.New("unsupported type for WebhookFilters")
+}
+
+func (WebhookFilters) GormDataType() string {
+	return "jsonb"
+}
+
+// A URL notified of the content and source events
+type Webhook struct {
+	Model
+
+	URL     string         `json:"url"`
+	Secret  string         `json:"-"` // Key of the HMAC signature of the deliveries, only given on creation
+	Events  FieldList      `gorm:"default:'[]'" json:"events" swaggertype:"array,string"`
+	Filters WebhookFilters `gorm:"default:'{}'" json:"filters"`
+	Enabled bool           `json:"enabled"`
+} // @name Webhook
+
+// Status of a webhook delivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // Given up after too many attempts
+)
+
+// An event to send to a webhook, written along with the change causing it
+type WebhookDelivery struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	WebhookID uint            `gorm:"index" json:"webhookId"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `gorm:"type:jsonb" json:"payload" swaggertype:"object"`
+
+	Status         string     `gorm:"index:idx_webhook_deliveries_pending,priority:1;default:pending" json:"status"` // pending, delivered or failed
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_deliveries_pending,priority:2" json:"nextAttemptAt"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"lastStatusCode"` // 0 when the webhook couldn't be reached
+	LastError      string     `json:"lastError"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+} // @name WebhookDelivery
+
 type Config struct {
 	Key       string         `gorm:"primaryKey" json:"key"`
 	Value     sql.NullString `json:"value"`
@@ -0,0 +1,29 @@
+package services
+
+// Items in the order of their IDs, and the IDs without item. An ID asked for
+// twice is only answered once
+func inRequestedOrder[T any](ids []string, items []T, id func(T) string) ([]T, []string) {
+	byID := make(map[string]T, len(items))
+	for _, item := range items {
+		byID[id(item)] = item
+	}
+
+	ordered := make([]T, 0, len(items))
+	missing := []string{}
+	seen := make(map[string]bool, len(ids))
+
+	for _, i := range ids {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+
+		if item, ok := byID[i]; ok {
+			ordered = append(ordered, item)
+		} else {
+			missing = append(missing, i)
+		}
+	}
+
+	return ordered, missing
+}
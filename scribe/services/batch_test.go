@@ -0,0 +1,19 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInRequestedOrder(t *testing.T) {
+	items := []string{"a", "c", "b"}
+
+	ordered, missing := inRequestedOrder([]string{"b", "x", "a", "b", "c"}, items, func(s string) string { return s })
+	require.Equal(t, []string{"b", "a", "c"}, ordered)
+	require.Equal(t, []string{"x"}, missing)
+
+	ordered, missing = inRequestedOrder([]string{"y"}, []string{}, func(s string) string { return s })
+	require.Empty(t, ordered)
+	require.Equal(t, []string{"y"}, missing)
+}
@@ -14,9 +14,11 @@ type ConfigKey = string
 const (
 	FeedlyToken          ConfigKey = "feedly_token"
 	FeedlyTokenExpiresAt ConfigKey = "feedly_token_expires_at"
+	RelevanceDictionary  ConfigKey = "relevance_dictionary"
+	IngestPipeline       ConfigKey = "ingest_pipeline"
 )
 
-var keys = []ConfigKey{FeedlyToken, FeedlyTokenExpiresAt}
+var keys = []ConfigKey{FeedlyToken, FeedlyTokenExpiresAt, RelevanceDictionary, IngestPipeline}
 
 type ConfigService struct {
 	db *gorm.DB
@@ -1,12 +1,241 @@
 package services
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/internal/dedup"
+	"github.com/skatekrak/scribe/internal/langdetect"
+	"github.com/skatekrak/scribe/internal/relevance"
+	"github.com/skatekrak/scribe/internal/sanitize"
 	"github.com/skatekrak/scribe/model"
 	"github.com/skatekrak/utils/database"
+	"github.com/skatekrak/utils/helpers"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// A stored content field that can be overwritten by a refresh or an edit
+type contentField struct {
+	name   string // JSON name, used in revisions and diffs
+	column string
+	get    func(c *model.Content) string
+	set    func(c *model.Content, value string) error
+}
+
+// Fields tracked by revisions. They're also the ones updated on conflict by AddMany
+var contentFields = []contentField{
+	{
+		name:   "title",
+		column: "title",
+		get:    func(c *model.Content) string { return c.Title },
+		set:    func(c *model.Content, v string) error { c.Title = v; return nil },
+	},
+	{
+		name:   "publishedAt",
+		column: "published_at",
+		get:    func(c *model.Content) string { return c.PublishedAt.UTC().Format(time.RFC3339) },
+		set: func(c *model.Content, v string) error {
+			t, err := time.Parse(time.RFC3339, v)
+			c.PublishedAt = t
+			return err
+		},
+	},
+	{
+		name:   "summary",
+		column: "summary",
+		get:    func(c *model.Content) string { return c.Summary },
+		set:    func(c *model.Content, v string) error { c.Summary = v; return nil },
+	},
+	{
+		name:   "rawSummary",
+		column: "raw_summary",
+		get:    func(c *model.Content) string { return c.RawSummary },
+		set:    func(c *model.Content, v string) error { c.RawSummary = v; return nil },
+	},
+	{
+		name:   "thumbnailUrl",
+		column: "thumbnail_url",
+		get:    func(c *model.Content) string { return c.ThumbnailURL },
+		set:    func(c *model.Content, v string) error { c.ThumbnailURL = v; return nil },
+	},
+}
+
+// Contents point at the canonical content of their group. Checked at commit, so
+// AddMany can redirect the duplicates of the contents it didn't insert beforehand
+const duplicatesMigration = `
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_contents_duplicate_of') THEN
+		UPDATE contents SET duplicate_of_id = NULL
+			WHERE duplicate_of_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM contents canonical WHERE canonical.id = contents.duplicate_of_id);
+		ALTER TABLE contents ADD CONSTRAINT fk_contents_duplicate_of FOREIGN KEY (duplicate_of_id)
+			REFERENCES contents (id) ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+	END IF;
+END
+$$;
+`
+
+// Add the foreign key of the duplicates, once. Runs after the auto migration
+func MigrateDuplicates(db *gorm.DB) error {
+	return db.Exec(duplicatesMigration).Error
+}
+
+// Video details, only overwritten by AddMany when they've been fetched
+var statsColumns = []string{"duration", "view_count", "like_count", "tags", "definition", "sub_type", "stats_updated_at"}
+
+// Computed from the fetched fields, overwritten along with them
+var derivedColumns = []string{"lang_iso_code", "lang_confidence"}
+
+// Assignments used by AddMany on conflict
+func contentUpdates() clause.Set {
+	updates := clause.AssignmentColumns(append(contentColumns(), derivedColumns...))
+
+	// Not scored when the relevance processor is disabled
+	updates = append(updates, clause.Assignment{
+		Column: clause.Column{Name: "relevance"},
+		Value:  gorm.Expr("COALESCE(excluded.relevance, contents.relevance)"),
+	})
+
+	for _, column := range statsColumns {
+		updates = append(updates, clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("CASE WHEN excluded.stats_updated_at IS NULL THEN contents.%s ELSE excluded.%s END", column, column)),
+		})
+	}
+
+	// Marked as updated for the sync only when one of the values shown changes
+	stored, assigned := []string{}, []string{}
+	for _, update := range updates {
+		if update.Column.Name == "stats_updated_at" {
+			continue
+		}
+		stored = append(stored, "contents."+update.Column.Name)
+		if expr, ok := update.Value.(clause.Expr); ok {
+			assigned = append(assigned, expr.SQL)
+		} else {
+			assigned = append(assigned, "excluded."+update.Column.Name)
+		}
+	}
+	updates = append(updates, clause.Assignment{
+		Column: clause.Column{Name: "updated_at"},
+		Value: gorm.Expr(fmt.Sprintf(
+			"CASE WHEN (%s) IS DISTINCT FROM (%s) THEN excluded.updated_at ELSE contents.updated_at END",
+			strings.Join(stored, ", "), strings.Join(assigned, ", "),
+		)),
+	})
+
+	return updates
+}
+
+// Update time of a content updated with the columns, only moved when one of their values changes
+func updatedAtIfChanged(columns map[string]interface{}) clause.Expr {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	placeholders := make([]string, len(names))
+	vars := make([]interface{}, 0, len(names)+1)
+	for i, name := range names {
+		placeholders[i] = "?"
+		vars = append(vars, columns[name])
+	}
+	vars = append(vars, time.Now())
+
+	return gorm.Expr(fmt.Sprintf(
+		"CASE WHEN (%s) IS DISTINCT FROM (%s) THEN ? ELSE contents.updated_at END",
+		strings.Join(names, ", "), strings.Join(placeholders, ", "),
+	), vars...)
+}
+
+func contentColumns() []string {
+	columns := make([]string, len(contentFields))
+	for i, f := range contentFields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+// Revisions needed to go from the stored content to the updated one
+func contentRevisions(stored *model.Content, updated *model.Content, cause string) []model.ContentRevision {
+	revisions := []model.ContentRevision{}
+
+	for _, f := range contentFields {
+		if oldValue, newValue := f.get(stored), f.get(updated); oldValue != newValue {
+			revisions = append(revisions, model.ContentRevision{
+				ContentID: stored.ID,
+				Field:     f.name,
+				OldValue:  oldValue,
+				NewValue:  newValue,
+				Cause:     cause,
+			})
+		}
+	}
+
+	return revisions
+}
+
+// Compute what duplicate detection compares
+func setFingerprint(c *model.Content) {
+	url := c.CanonicalURL
+	if url == "" {
+		url = c.ContentURL
+	}
+
+	c.NormalizedURL = dedup.NormalizeURL(url)
+	c.Simhash = int64(dedup.Simhash(c.Summary))
+}
+
+func fingerprint(c *model.Content) dedup.Fingerprint {
+	return dedup.Fingerprint{
+		SourceID:      c.SourceID,
+		NormalizedURL: c.NormalizedURL,
+		Title:         c.Title,
+		Simhash:       uint64(c.Simhash),
+	}
+}
+
+// Detect the language of the content from its title and summary
+func detectLang(c *model.Content) {
+	c.LangIsoCode, c.LangConfidence = langdetect.Detect(c.Title + "\n" + c.Summary)
+}
+
+// Put back the stored value of every field locked on the content or its source
+func keepLockedFields(stored *model.Content, incoming *model.Content, sourceLocks model.FieldList) {
+	for _, f := range contentFields {
+		if stored.LockedFields.Has(f.name) || sourceLocks.Has(f.name) {
+			// Stored values have been formatted by get, they can always be set back
+			_ = f.set(incoming, f.get(stored))
+		}
+	}
+}
+
+// Whether the lang processor ran on the content
+func langDetected(c *model.Content) bool {
+	return c.LangIsoCode != "" || c.LangConfidence != 0
+}
+
+// Prepare the incoming content to overwrite the stored one: put back the locked
+// fields and compute again what depends on them. The lang and relevance are only
+// computed again when the incoming content went through their processor, the
+// stored lang is kept otherwise
+func keepLockedFieldsAndRescore(stored *model.Content, incoming *model.Content, scorer *relevance.Scorer) {
+	keepLockedFields(stored, incoming, stored.Source.LockedContentFields)
+	if langDetected(incoming) {
+		detectLang(incoming)
+	} else {
+		incoming.LangIsoCode, incoming.LangConfidence = stored.LangIsoCode, stored.LangConfidence
+	}
+	if incoming.Relevance != nil {
+		scoreRelevance(scorer, incoming, stored.Source.SkateSource)
+	}
+}
+
 type ContentService struct {
 	db *gorm.DB
 }
@@ -15,33 +244,131 @@ func NewContentService(db *gorm.DB) *ContentService {
 	return &ContentService{db}
 }
 
-func (s *ContentService) Find(sourceTypes []string, sources []int, page int) (*database.Pagination, error) {
-	pagination := &database.Pagination{
-		PerPage: 50,
-		Page:    page,
-		Items:   []model.Content{},
+// Filters of ContentService.Find, empty ones are ignored
+type ContentFilters struct {
+	SourceTypes     []string
+	Sources         []int
+	ExcludeSources  []int
+	Types           []string // video or article
+	SubTypes        []string
+	Langs           []string // Detected lang of the content, or lang of its source
+	SkateOnly       bool     // Only the contents of skate sources
+	PublishedAfter  *time.Time
+	PublishedBefore *time.Time
+	MinRelevance    *float64
+	Collapse        bool // Only list the canonical content of each group of duplicates
+}
+
+// Orders of ContentService.Find
+const (
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+	SortRelevance = "relevance" // Most about skateboarding first
+)
+
+func sortContents(tx *gorm.DB, sort string) *gorm.DB {
+	switch sort {
+	case SortOldest:
+		return tx.Order("contents.published_at asc, contents.id asc")
+	case SortRelevance:
+		return tx.Order("contents.relevance desc nulls last, contents.published_at desc, contents.id desc")
+	default:
+		return tx.Order("contents.published_at desc, contents.id desc")
 	}
+}
 
-	tx := s.db.Model(pagination.Items).
-		Where("contents.published_at IS NOT NULL").
-		Order("contents.published_at desc").
-		Joins("Source").
-		Session(&gorm.Session{})
+// Whether a content with its source matches the filters, like filterContents does in SQL
+func (filters ContentFilters) Match(c *model.Content) bool {
+	lang := c.LangIsoCode
+	if lang == "" {
+		lang = c.Source.LangIsoCode
+	}
 
-	if len(sourceTypes)+len(sources) > 0 {
+	switch {
+	case len(filters.SourceTypes) > 0 && !helpers.Has(filters.SourceTypes, c.Source.SourceType),
+		len(filters.Sources) > 0 && !helpers.Has(filters.Sources, int(c.SourceID)),
+		helpers.Has(filters.ExcludeSources, int(c.SourceID)),
+		len(filters.Types) > 0 && !helpers.Has(filters.Types, c.Type),
+		len(filters.SubTypes) > 0 && !helpers.Has(filters.SubTypes, c.SubType),
+		len(filters.Langs) > 0 && !helpers.Has(filters.Langs, lang),
+		filters.SkateOnly && !c.Source.SkateSource,
+		filters.PublishedAfter != nil && c.PublishedAt.Before(*filters.PublishedAfter),
+		filters.PublishedBefore != nil && !c.PublishedAt.Before(*filters.PublishedBefore),
+		filters.MinRelevance != nil && (c.Relevance == nil || *c.Relevance < *filters.MinRelevance),
+		filters.Collapse && c.DuplicateOfID != nil:
+		return false
+	}
+	return true
+}
+
+// Apply the filters to a query on contents
+func filterContents(tx *gorm.DB, filters ContentFilters) *gorm.DB {
+	if len(filters.SourceTypes)+len(filters.Langs) > 0 || filters.SkateOnly {
 		tx = tx.Joins("JOIN sources ON sources.id = contents.source_id")
 	}
 
-	if len(sourceTypes) > 0 {
+	if len(filters.SourceTypes) > 0 {
 		tx = tx.
-			Where("sources.source_type in ?", sourceTypes).
+			Where("sources.source_type in ?", filters.SourceTypes).
 			Session(&gorm.Session{})
 	}
 
-	if len(sources) > 0 {
-		tx = tx.Where("sources.id in ?", sources)
+	if len(filters.Sources) > 0 {
+		tx = tx.Where("contents.source_id in ?", filters.Sources)
 	}
 
+	if len(filters.ExcludeSources) > 0 {
+		tx = tx.Where("contents.source_id not in ?", filters.ExcludeSources)
+	}
+
+	if len(filters.Types) > 0 {
+		tx = tx.Where("contents.type in ?", filters.Types)
+	}
+
+	if len(filters.SubTypes) > 0 {
+		tx = tx.Where("contents.sub_type in ?", filters.SubTypes)
+	}
+
+	if filters.SkateOnly {
+		tx = tx.Where("sources.skate_source = ?", true)
+	}
+
+	if filters.PublishedAfter != nil {
+		tx = tx.Where("contents.published_at >= ?", *filters.PublishedAfter)
+	}
+
+	if filters.PublishedBefore != nil {
+		tx = tx.Where("contents.published_at < ?", *filters.PublishedBefore)
+	}
+
+	if len(filters.Langs) > 0 {
+		tx = tx.Where("COALESCE(NULLIF(contents.lang_iso_code, ''), sources.lang_iso_code) in ?", filters.Langs)
+	}
+
+	if filters.MinRelevance != nil {
+		tx = tx.Where("contents.relevance >= ?", *filters.MinRelevance)
+	}
+
+	if filters.Collapse {
+		tx = tx.Where("contents.duplicate_of_id IS NULL")
+	}
+
+	return tx
+}
+
+func (s *ContentService) Find(filters ContentFilters, sort string, page int, projection Projection) (*database.Pagination, error) {
+	pagination := &database.Pagination{
+		PerPage: 50,
+		Page:    page,
+		Items:   []model.Content{},
+	}
+
+	tx := projection.apply(s.db.Model(pagination.Items)).
+		Where("contents.published_at IS NOT NULL").
+		Session(&gorm.Session{})
+
+	tx = sortContents(filterContents(tx, filters), sort)
+
 	tx = tx.
 		Scopes(pagination.Scope()).
 		Find(&pagination.Items)
@@ -49,21 +376,211 @@ func (s *ContentService) Find(sourceTypes []string, sources []int, page int) (*d
 	return pagination, tx.Error
 }
 
+// Contents with the IDs, in the order of the IDs, and the IDs not found
+func (s *ContentService) FindByIDs(ids []string, projection Projection) ([]model.Content, []string, error) {
+	var contents []model.Content
+	err := projection.apply(s.db).
+		Where("contents.id IN ?", ids).
+		Find(&contents).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents, missing := inRequestedOrder(ids, contents, func(c model.Content) string { return c.ID })
+	return contents, missing, nil
+}
+
+// Latest contents matching the filters, newest first, for the syndication feeds
+func (s *ContentService) FindLatest(filters ContentFilters, limit int) ([]model.Content, error) {
+	tx := s.db.Model(&model.Content{}).
+		Where("contents.published_at IS NOT NULL").
+		Joins("Source").
+		Preload("ThumbnailImage")
+
+	var contents []model.Content
+	err := sortContents(filterContents(tx, filters), SortNewest).
+		Limit(limit).
+		Find(&contents).Error
+	return contents, err
+}
+
+// Contents created after the cursor, oldest first, to resume a stream
+func (s *ContentService) FindCreatedAfter(filters ContentFilters, cursor database.Cursor, limit int) ([]model.Content, error) {
+	tx := s.db.Model(&model.Content{}).
+		Where("(contents.created_at, contents.id) > (?, ?)", cursor.Time, cursor.ID).
+		Joins("Source").
+		Preload("Source.IconImage").
+		Preload("ThumbnailImage")
+
+	var contents []model.Content
+	err := filterContents(tx, filters).
+		Order("contents.created_at asc, contents.id asc").
+		Limit(limit).
+		Find(&contents).Error
+	return contents, err
+}
+
+// Order of the contents feed, also used as its cursor
+var feedKeyset = database.Keyset{TimeColumn: "contents.published_at", IDColumn: "contents.id"}
+
+// Like Find sorted by SortNewest, but paginated with a cursor so new contents don't shift the pages.
+// The total is only counted when asked, it's a slow query on the whole feed
+func (s *ContentService) FindWithCursor(filters ContentFilters, cursor string, limit int, count bool, projection Projection) (*database.CursorPagination, error) {
+	pagination := &database.CursorPagination{Limit: limit}
+
+	tx := projection.apply(s.db.Model(&model.Content{})).
+		Where("contents.published_at IS NOT NULL").
+		Session(&gorm.Session{})
+
+	tx = filterContents(tx, filters)
+
+	err := database.FindWithCursor(tx, feedKeyset, cursor, pagination, count, func(c model.Content) (time.Time, string) {
+		return c.PublishedAt, c.ID
+	})
+	return pagination, err
+}
+
 func (s *ContentService) Get(id string) (model.Content, error) {
 	var content model.Content
-	err := s.db.Where("contents.id = ?", id).Joins("Source").Preload("Source.Lang").First(&content).Error
+	err := s.db.Where("contents.id = ?", id).Joins("Source").Preload("Source.Lang").Preload("Source.IconImage").Preload("ThumbnailImage").First(&content).Error
 	return content, err
 }
 
-func (s *ContentService) AddMany(contents []*model.Content, sources []*model.Source) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+// Contents given to AddMany, split between the ones it inserted, the ones
+// that were already stored and the deleted ones it left as they are
+type IngestResult struct {
+	Inserted []*model.Content
+	Present  []*model.Content
+	Deleted  []*model.Content
+}
+
+// Max number of content IDs in a single IN query
+const lookupBatchSize = 500
+
+// Stored contents with the given content IDs, keyed by content ID, with their
+// source. The deleted ones are included, so they aren't taken for new ones
+func findByContentIDs(db *gorm.DB, contentIDs []string) (map[string]model.Content, error) {
+	stored := make(map[string]model.Content, len(contentIDs))
+
+	for start := 0; start < len(contentIDs); start += lookupBatchSize {
+		end := start + lookupBatchSize
+		if end > len(contentIDs) {
+			end = len(contentIDs)
+		}
+
+		var contents []model.Content
+		if err := db.Unscoped().Joins("Source").Where("contents.content_id IN ?", contentIDs[start:end]).Find(&contents).Error; err != nil {
+			return stored, err
+		}
+
+		for _, content := range contents {
+			stored[content.ContentID] = content
+		}
+	}
+
+	return stored, nil
+}
+
+func (s *ContentService) FindByContentIDs(contentIDs []string) (map[string]model.Content, error) {
+	return findByContentIDs(s.db, contentIDs)
+}
+
+// Insert the contents, or update the tracked fields of the ones already stored,
+// except the locked ones. Every change of an already stored content is kept as
+// a revision with the given cause.
+func (s *ContentService) AddMany(contents []*model.Content, sources []*model.Source, cause string) (*IngestResult, error) {
+	result := &IngestResult{
+		Inserted: []*model.Content{},
+		Present:  []*model.Content{},
+		Deleted:  []*model.Content{},
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		contentIDs := make([]string, len(contents))
+		for i, content := range contents {
+			contentIDs[i] = content.ContentID
+		}
+
+		stored, err := findByContentIDs(tx, contentIDs)
+		if err != nil {
+			return err
+		}
+
+		// IDs given by the dedupe processor that won't be saved, with the ID replacing them
+		discarded := map[string]*string{}
+
+		contents, result.Deleted = withoutDeleted(contents, stored, discarded)
+		contentIDs = contentIDs[:0]
+		for _, content := range contents {
+			contentIDs = append(contentIDs, content.ContentID)
+		}
+
+		scorer, err := NewConfigService(tx).RelevanceScorer()
+		if err != nil {
+			return err
+		}
+
+		revisions := []model.ContentRevision{}
+		for _, content := range contents {
+			if storedContent, ok := stored[content.ContentID]; ok {
+				// The locked title or summary may be put back
+				keepLockedFieldsAndRescore(&storedContent, content, scorer)
+				revisions = append(revisions, contentRevisions(&storedContent, content, cause)...)
+			}
+		}
+
 		if err := tx.Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "content_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"title", "published_at", "summary", "raw_summary", "thumbnail_url"}),
+			DoUpdates: contentUpdates(),
+			// Deleted in the meantime
+			Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "contents.deleted_at IS NULL"}}},
 		}).CreateInBatches(contents, len(contents)).Error; err != nil {
 			return err
 		}
 
+		// Contents stored in the meantime kept their ID, the new one is only on the inserted rows
+		saved, err := findByContentIDs(tx, contentIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, content := range contents {
+			savedContent, ok := saved[content.ContentID]
+			_, wasStored := stored[content.ContentID]
+
+			if ok && (wasStored || savedContent.ID != content.ID) {
+				if !wasStored && content.ID != "" {
+					discarded[content.ID] = canonicalID(&savedContent)
+				}
+				content.ID = savedContent.ID
+				content.DuplicateOfID = savedContent.DuplicateOfID
+				result.Present = append(result.Present, content)
+			} else {
+				result.Inserted = append(result.Inserted, content)
+			}
+		}
+
+		if err := redirectInsertedDuplicates(tx, result.Inserted, discarded); err != nil {
+			return err
+		}
+
+		// Streamed once committed
+		for _, content := range result.Inserted {
+			if err := tx.Exec("SELECT pg_notify(?, ?)", ContentsChannel, content.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := enqueueContentWebhooks(tx, result.Inserted); err != nil {
+			return err
+		}
+
+		if len(revisions) > 0 {
+			if err := tx.Create(&revisions).Error; err != nil {
+				return err
+			}
+		}
+
 		for i := range sources {
 			if err := tx.Save(&sources[i]).Error; err != nil {
 				return err
@@ -72,10 +589,426 @@ func (s *ContentService) AddMany(contents []*model.Content, sources []*model.Sou
 
 		return nil
 	})
+
+	return result, err
 }
 
-func (s *ContentService) FindOneByContentID(contentID string) (model.Content, error) {
-	var content model.Content
-	err := s.db.Where("content_id = ?", contentID).First(&content).Error
-	return content, err
+// Split the contents between the ones to save and the ones deleted since they were
+// stored, which are left deleted. The deleted ones are given their stored ID, the
+// one they had is added to the discarded ones
+func withoutDeleted(contents []*model.Content, stored map[string]model.Content, discarded map[string]*string) ([]*model.Content, []*model.Content) {
+	kept := []*model.Content{}
+	deleted := []*model.Content{}
+
+	for _, content := range contents {
+		if storedContent, ok := stored[content.ContentID]; ok && storedContent.DeletedAt.Valid {
+			if content.ID != "" && content.ID != storedContent.ID {
+				discarded[content.ID] = nil
+			}
+			content.ID = storedContent.ID
+			deleted = append(deleted, content)
+			continue
+		}
+		kept = append(kept, content)
+	}
+
+	return kept, deleted
+}
+
+// ID of the canonical content of the group of the content
+func canonicalID(content *model.Content) *string {
+	if content.DuplicateOfID != nil {
+		return content.DuplicateOfID
+	}
+	id := content.ID
+	return &id
+}
+
+// Point the duplicates of the discarded contents at the content replacing them.
+// Without replacement, the earliest duplicate left becomes the canonical content
+// of the others. Returns the contents changed
+func redirectDuplicates(contents []*model.Content, discarded map[string]*string) []*model.Content {
+	changed := []*model.Content{}
+	if len(discarded) == 0 {
+		return changed
+	}
+
+	sorted := make([]*model.Content, len(contents))
+	copy(sorted, contents)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.Before(sorted[j].PublishedAt)
+	})
+
+	for _, content := range sorted {
+		if content.DuplicateOfID == nil {
+			continue
+		}
+		replacement, ok := discarded[*content.DuplicateOfID]
+		if !ok {
+			continue
+		}
+
+		if replacement == nil {
+			id := content.ID
+			discarded[*content.DuplicateOfID] = &id
+		}
+		content.DuplicateOfID = replacement
+		changed = append(changed, content)
+	}
+	return changed
+}
+
+// Redirect the duplicates of the discarded contents among the inserted ones, in
+// the database too. The foreign key is deferred until then
+func redirectInsertedDuplicates(tx *gorm.DB, inserted []*model.Content, discarded map[string]*string) error {
+	for _, content := range redirectDuplicates(inserted, discarded) {
+		if err := tx.Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumn("duplicate_of_id", content.DuplicateOfID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save the tracked fields of updated that differ from stored, along with their
+// revisions. The lang and relevance follow the title and summary
+func (s *ContentService) Update(stored *model.Content, updated *model.Content, cause string) error {
+	// Edited like fetched, the HTML is sanitized
+	if updated.RawSummary != stored.RawSummary {
+		updated.RawSummary = sanitize.HTML(updated.RawSummary, updated.ContentURL)
+	}
+
+	revisions := contentRevisions(stored, updated, cause)
+	if len(revisions) <= 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{}
+		for _, f := range contentFields {
+			for _, revision := range revisions {
+				if revision.Field == f.name {
+					updates[f.column] = f.get(updated)
+				}
+			}
+		}
+
+		_, title := updates["title"]
+		_, summary := updates["summary"]
+		if title || summary {
+			detectLang(updated)
+			updates["lang_iso_code"] = updated.LangIsoCode
+			updates["lang_confidence"] = updated.LangConfidence
+
+			scorer, err := NewConfigService(tx).RelevanceScorer()
+			if err != nil {
+				return err
+			}
+			scoreRelevance(scorer, updated, stored.Source.SkateSource)
+			updates["relevance"] = updated.Relevance
+		}
+		if summary {
+			setFingerprint(updated)
+			updates["simhash"] = updated.Simhash
+		}
+
+		if err := tx.Model(&model.Content{}).Where("id = ?", stored.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&revisions).Error
+	})
+}
+
+func (s *ContentService) FindRevisions(contentID string) ([]model.ContentRevision, error) {
+	var revisions []model.ContentRevision
+	err := s.db.Where("content_id = ?", contentID).Order("id desc").Find(&revisions).Error
+	return revisions, err
+}
+
+func (s *ContentService) GetRevision(contentID string, revisionID string) (model.ContentRevision, error) {
+	var revision model.ContentRevision
+	err := s.db.Where("content_id = ? AND id = ?", contentID, revisionID).First(&revision).Error
+	return revision, err
+}
+
+// Restore the content as it was right after the given revision, by undoing every later revision
+func (s *ContentService) Revert(content *model.Content, revision model.ContentRevision) (model.Content, error) {
+	var later []model.ContentRevision
+	if err := s.db.Where("content_id = ? AND id > ?", content.ID, revision.ID).Order("id desc").Find(&later).Error; err != nil {
+		return *content, err
+	}
+
+	reverted := *content
+	for _, r := range append(later, revision) {
+		value := r.OldValue
+		if r.ID == revision.ID {
+			value = r.NewValue
+		}
+
+		for _, f := range contentFields {
+			if f.name == r.Field {
+				if err := f.set(&reverted, value); err != nil {
+					return *content, err
+				}
+			}
+		}
+	}
+
+	if err := s.Update(content, &reverted, model.RevisionCauseRevert); err != nil {
+		return *content, err
+	}
+
+	return reverted, nil
+}
+
+func (s *ContentService) SetLockedFields(content *model.Content, fields model.FieldList) error {
+	if err := s.db.Model(&model.Content{}).Where("id = ?", content.ID).Update("locked_fields", fields).Error; err != nil {
+		return err
+	}
+
+	content.LockedFields = fields
+	return nil
+}
+
+// Sanitize again the raw summary and content of every stored content, returns
+// the number of updated contents. It isn't an editorial change so no revision is kept.
+func (s *ContentService) SanitizeAll(batchSize int) (int, error) {
+	updated := 0
+	var contents []model.Content
+
+	err := s.db.Unscoped().Select("id", "content_url", "raw_summary", "raw_content").FindInBatches(&contents, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, content := range contents {
+			rawSummary := sanitize.HTML(content.RawSummary, content.ContentURL)
+			rawContent := sanitize.HTML(content.RawContent, content.ContentURL)
+
+			if rawSummary == content.RawSummary && rawContent == content.RawContent {
+				continue
+			}
+
+			columns := map[string]interface{}{
+				"raw_summary": rawSummary,
+				"raw_content": rawContent,
+			}
+			columns["updated_at"] = updatedAtIfChanged(columns)
+			if err := s.db.Unscoped().Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumns(columns).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+
+		return nil
+	}).Error
+
+	return updated, err
+}
+
+// Detect the lang of every stored content, returns the number of updated contents
+func (s *ContentService) DetectLangAll(batchSize int) (int, error) {
+	updated := 0
+	var contents []model.Content
+
+	err := s.db.Unscoped().Select("id", "title", "summary", "lang_iso_code", "lang_confidence").FindInBatches(&contents, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, content := range contents {
+			detected := content
+			detectLang(&detected)
+
+			if detected.LangIsoCode == content.LangIsoCode && detected.LangConfidence == content.LangConfidence {
+				continue
+			}
+
+			columns := map[string]interface{}{
+				"lang_iso_code":   detected.LangIsoCode,
+				"lang_confidence": detected.LangConfidence,
+			}
+			columns["updated_at"] = updatedAtIfChanged(columns)
+			if err := s.db.Unscoped().Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumns(columns).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+
+		return nil
+	}).Error
+
+	return updated, err
+}
+
+// Score again the relevance of every stored content, returns the number of updated contents
+func (s *ContentService) ScoreRelevanceAll(scorer *relevance.Scorer, batchSize int) (int, error) {
+	updated := 0
+	var contents []model.Content
+
+	err := s.db.Unscoped().Joins("Source").FindInBatches(&contents, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, content := range contents {
+			scored := content
+			scoreRelevance(scorer, &scored, content.Source.SkateSource)
+
+			if content.Relevance != nil && *scored.Relevance == *content.Relevance {
+				continue
+			}
+
+			columns := map[string]interface{}{"relevance": scored.Relevance}
+			columns["updated_at"] = updatedAtIfChanged(columns)
+			if err := s.db.Unscoped().Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumns(columns).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+
+		return nil
+	}).Error
+
+	return updated, err
+}
+
+// Time between two dates
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Candidates loaded at most per period to find the duplicates of a batch of new
+// contents. The newest are kept, the new contents are more likely to duplicate them
+const maxDuplicateCandidates = 5000
+
+var duplicateCandidateColumns = []string{"id", "content_id", "source_id", "title", "normalized_url", "simhash", "duplicate_of_id", "published_at"}
+
+// Contents new contents could duplicate: all the ones with one of the URLs,
+// and the ones published in each of the periods, the earliest first
+func (s *ContentService) FindDuplicateCandidates(urls []string, periods []Period) ([]*model.Content, error) {
+	lists := [][]*model.Content{}
+
+	if len(urls) > 0 {
+		var contents []*model.Content
+		if err := s.db.Select(duplicateCandidateColumns).Where("normalized_url IN ?", urls).Find(&contents).Error; err != nil {
+			return nil, err
+		}
+		lists = append(lists, contents)
+	}
+
+	for _, period := range periods {
+		var contents []*model.Content
+		err := s.db.Select(duplicateCandidateColumns).
+			Where("published_at BETWEEN ? AND ?", period.From, period.To).
+			Order("published_at desc").
+			Limit(maxDuplicateCandidates).
+			Find(&contents).Error
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, contents)
+	}
+
+	return mergeCandidates(lists...), nil
+}
+
+// Contents of the lists, once each, the earliest first
+func mergeCandidates(lists ...[]*model.Content) []*model.Content {
+	seen := map[string]bool{}
+	merged := []*model.Content{}
+	for _, contents := range lists {
+		for _, content := range contents {
+			if !seen[content.ID] {
+				seen[content.ID] = true
+				merged = append(merged, content)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishedAt.Before(merged[j].PublishedAt)
+	})
+	return merged
+}
+
+// The other contents of the group of the content
+func (s *ContentService) FindDuplicates(content *model.Content) ([]model.Content, error) {
+	canonicalID := content.ID
+	if content.DuplicateOfID != nil {
+		canonicalID = *content.DuplicateOfID
+	}
+
+	var contents []model.Content
+	err := s.db.Joins("Source").
+		Preload("Source.IconImage").
+		Preload("ThumbnailImage").
+		Where("(contents.id = ? OR contents.duplicate_of_id = ?) AND contents.id != ?", canonicalID, canonicalID, content.ID).
+		Order("contents.published_at asc").
+		Find(&contents).Error
+	return contents, err
+}
+
+// Compute the fingerprint of every stored content, so new contents can be
+// found as their duplicates. Returns the number of updated contents.
+func (s *ContentService) FingerprintAll(batchSize int) (int, error) {
+	updated := 0
+	var contents []model.Content
+
+	err := s.db.Unscoped().Select("id", "summary", "content_url", "canonical_url", "normalized_url", "simhash").FindInBatches(&contents, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, content := range contents {
+			fingerprinted := content
+			setFingerprint(&fingerprinted)
+
+			if fingerprinted.NormalizedURL == content.NormalizedURL && fingerprinted.Simhash == content.Simhash {
+				continue
+			}
+
+			if err := s.db.Unscoped().Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumns(map[string]interface{}{
+				"normalized_url": fingerprinted.NormalizedURL,
+				"simhash":        fingerprinted.Simhash,
+			}).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+
+		return nil
+	}).Error
+
+	return updated, err
+}
+
+func (s *ContentService) FindVideosPublishedSince(since time.Time) ([]model.Content, error) {
+	var contents []model.Content
+	err := s.db.Joins("Source").
+		Where("contents.type = ?", "video").
+		Where("contents.published_at >= ?", since).
+		Find(&contents).Error
+	return contents, err
+}
+
+func (s *ContentService) FindVideosBySubTypes(subTypes []string) ([]model.Content, error) {
+	var contents []model.Content
+	err := s.db.Joins("Source").
+		Where("contents.type = ?", "video").
+		Where("contents.sub_type IN ?", subTypes).
+		Find(&contents).Error
+	return contents, err
+}
+
+// Save the fetched details of a video. A live stream that started is moved to its start time.
+func (s *ContentService) UpdateDetails(content model.Content, details fetchers.VideoDetails) error {
+	columns := map[string]interface{}{
+		"duration":   details.Duration,
+		"view_count": details.ViewCount,
+		"like_count": details.LikeCount,
+		"tags":       model.FieldList(details.Tags),
+		"definition": details.Definition,
+		"sub_type":   details.SubType,
+	}
+	columns["updated_at"] = updatedAtIfChanged(columns)
+	columns["stats_updated_at"] = time.Now()
+	if err := s.db.Model(&model.Content{}).Where("id = ?", content.ID).UpdateColumns(columns).Error; err != nil {
+		return err
+	}
+
+	if details.StartedAt == nil {
+		return nil
+	}
+
+	updated := content
+	updated.PublishedAt = *details.StartedAt
+	keepLockedFields(&content, &updated, content.Source.LockedContentFields)
+
+	return s.Update(&content, &updated, model.RevisionCauseRefresh)
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skatekrak/scribe/internal/relevance"
+	"github.com/skatekrak/scribe/model"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestContentFiltersMatch(t *testing.T) {
+	relevance := 0.7
+	canonicalID := "canonical"
+	published := time.Date(2022, 7, 14, 18, 30, 0, 0, time.UTC)
+
+	content := &model.Content{
+		SourceID:    3,
+		Source:      model.Source{SourceType: "youtube", LangIsoCode: "en", SkateSource: true},
+		Type:        "video",
+		SubType:     "regular",
+		PublishedAt: published,
+		Relevance:   &relevance,
+	}
+
+	minRelevance := 0.5
+	tooRelevant := 0.8
+	before := published.Add(time.Hour)
+	after := published.Add(-time.Hour)
+
+	for name, test := range map[string]struct {
+		filters ContentFilters
+		match   bool
+	}{
+		"no filters":          {ContentFilters{}, true},
+		"source type":         {ContentFilters{SourceTypes: []string{"vimeo", "youtube"}}, true},
+		"other source type":   {ContentFilters{SourceTypes: []string{"rss"}}, false},
+		"source":              {ContentFilters{Sources: []int{3}}, true},
+		"other source":        {ContentFilters{Sources: []int{4}}, false},
+		"excluded source":     {ContentFilters{ExcludeSources: []int{3}}, false},
+		"type":                {ContentFilters{Types: []string{"article"}}, false},
+		"sub type":            {ContentFilters{SubTypes: []string{"regular"}}, true},
+		"source lang":         {ContentFilters{Langs: []string{"en"}}, true},
+		"other lang":          {ContentFilters{Langs: []string{"fr"}}, false},
+		"skate only":          {ContentFilters{SkateOnly: true}, true},
+		"published in range":  {ContentFilters{PublishedAfter: &after, PublishedBefore: &before}, true},
+		"published before":    {ContentFilters{PublishedBefore: &published}, false},
+		"relevant enough":     {ContentFilters{MinRelevance: &minRelevance}, true},
+		"not relevant enough": {ContentFilters{MinRelevance: &tooRelevant}, false},
+		"canonical":           {ContentFilters{Collapse: true}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.match, test.filters.Match(content))
+		})
+	}
+
+	t.Run("detected lang first", func(t *testing.T) {
+		detected := *content
+		detected.LangIsoCode = "fr"
+		require.True(t, ContentFilters{Langs: []string{"fr"}}.Match(&detected))
+		require.False(t, ContentFilters{Langs: []string{"en"}}.Match(&detected))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		duplicate := *content
+		duplicate.DuplicateOfID = &canonicalID
+		require.False(t, ContentFilters{Collapse: true}.Match(&duplicate))
+	})
+
+	t.Run("not scored", func(t *testing.T) {
+		unscored := *content
+		unscored.Relevance = nil
+		require.False(t, ContentFilters{MinRelevance: &minRelevance}.Match(&unscored))
+	})
+}
+
+func TestWithoutDeleted(t *testing.T) {
+	stored := map[string]model.Content{
+		"kept":    {ID: "1", ContentID: "kept"},
+		"deleted": {ID: "2", ContentID: "deleted", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+	}
+	kept := &model.Content{ContentID: "kept"}
+	deleted := &model.Content{ID: "new", ContentID: "deleted"}
+	added := &model.Content{ContentID: "added"}
+
+	discarded := map[string]*string{}
+	contents, skipped := withoutDeleted([]*model.Content{kept, deleted, added}, stored, discarded)
+	require.Equal(t, []*model.Content{kept, added}, contents)
+	require.Equal(t, []*model.Content{deleted}, skipped)
+	require.Equal(t, "2", deleted.ID)
+	require.Equal(t, map[string]*string{"new": nil}, discarded)
+}
+
+func TestRedirectDuplicates(t *testing.T) {
+	now := time.Now()
+	stored, dropped := "stored", "dropped"
+	contents := []*model.Content{
+		{ID: "c", PublishedAt: now.Add(2 * time.Hour), DuplicateOfID: &dropped},
+		{ID: "b", PublishedAt: now.Add(time.Hour), DuplicateOfID: &dropped},
+		{ID: "a", PublishedAt: now, DuplicateOfID: &stored},
+		{ID: "canonical", PublishedAt: now},
+	}
+
+	saved := "saved"
+	changed := redirectDuplicates(contents, map[string]*string{"stored": &saved, "dropped": nil})
+	require.Len(t, changed, 3)
+
+	// The earliest duplicate of the dropped content takes its place
+	require.Equal(t, "saved", *contents[2].DuplicateOfID)
+	require.Nil(t, contents[1].DuplicateOfID)
+	require.Equal(t, "b", *contents[0].DuplicateOfID)
+	require.Nil(t, contents[3].DuplicateOfID)
+
+	require.Empty(t, redirectDuplicates(contents, map[string]*string{}))
+}
+
+// Database rendering the SQL of the queries without running them
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DisableAutomaticPing: true,
+		DryRun:               true,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+// SQL of the last query run on the database, with its variables
+func lastQuery(t *testing.T, db *gorm.DB) *string {
+	sql := new(string)
+	err := db.Callback().Query().After("gorm:query").Register("test:last_query", func(tx *gorm.DB) {
+		*sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	})
+	require.NoError(t, err)
+	return sql
+}
+
+func TestFindDuplicateCandidates(t *testing.T) {
+	db := dryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+		queries = append(queries, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	})
+	require.NoError(t, err)
+	from := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err = NewContentService(db).FindDuplicateCandidates([]string{"example.com/news"}, []Period{
+		{from, from.AddDate(0, 0, 14)},
+		{from.AddDate(0, 1, 0), from.AddDate(0, 1, 14)},
+	})
+	require.NoError(t, err)
+	require.Len(t, queries, 3)
+
+	// Every content of the same page, whatever the number of candidates
+	require.Contains(t, queries[0], `WHERE normalized_url IN ('example.com/news')`)
+	require.NotContains(t, queries[0], "LIMIT")
+
+	// Over the cap, the oldest contents of a period are left out
+	require.Contains(t, queries[1], `WHERE (published_at BETWEEN '2022-09-01 00:00:00' AND '2022-09-15 00:00:00')`)
+	require.Contains(t, queries[1], "ORDER BY published_at desc LIMIT 5000")
+	require.Contains(t, queries[2], `WHERE (published_at BETWEEN '2022-10-01 00:00:00' AND '2022-10-15 00:00:00')`)
+	require.Contains(t, queries[2], "ORDER BY published_at desc LIMIT 5000")
+}
+
+func TestMergeCandidates(t *testing.T) {
+	now := time.Now()
+	page := &model.Content{ID: "page", PublishedAt: now.AddDate(0, -6, 0)}
+	older := &model.Content{ID: "older", PublishedAt: now.Add(-time.Hour)}
+	newer := &model.Content{ID: "newer", PublishedAt: now}
+
+	merged := mergeCandidates([]*model.Content{page, newer}, []*model.Content{newer, older})
+	require.Equal(t, []*model.Content{page, older, newer}, merged)
+}
+
+func TestUpdatedAtIfChanged(t *testing.T) {
+	db := dryRunDB(t)
+
+	columns := map[string]interface{}{"title": "Title", "summary": "Summary"}
+	columns["updated_at"] = updatedAtIfChanged(columns)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&model.Content{}).Where("id = ?", "1").UpdateColumns(columns)
+	})
+	require.Contains(t, sql, `"updated_at"=CASE WHEN (summary, title) IS DISTINCT FROM ('Summary', 'Title') THEN`)
+	require.Contains(t, sql, "ELSE contents.updated_at END")
+}
+
+func TestKeepLockedFieldsAndRescore(t *testing.T) {
+	scorer := relevance.NewScorer(relevance.Dictionary{
+		SkateSourcePrior: 0.8,
+		OtherSourcePrior: 0.2,
+		TitleWeight:      2,
+		Terms:            []relevance.Term{{Phrase: "kickflip", Weight: 2}},
+	})
+	unscored := 0.2
+
+	stored := &model.Content{
+		Title:          "Best kickflip of the year, down a huge handrail in the city",
+		LockedFields:   model.FieldList{"title"},
+		LangIsoCode:    "fr",
+		LangConfidence: 0.5,
+	}
+
+	t.Run("scored on the locked title", func(t *testing.T) {
+		incoming := &model.Content{Title: "You won't believe this", Relevance: &unscored, LangConfidence: 0.1}
+		keepLockedFieldsAndRescore(stored, incoming, scorer)
+		require.Equal(t, stored.Title, incoming.Title)
+		require.Greater(t, *incoming.Relevance, unscored)
+		require.Equal(t, "en", incoming.LangIsoCode)
+	})
+
+	t.Run("not scored", func(t *testing.T) {
+		incoming := &model.Content{Title: "You won't believe this"}
+		keepLockedFieldsAndRescore(stored, incoming, scorer)
+		require.Nil(t, incoming.Relevance)
+	})
+
+	t.Run("lang not detected", func(t *testing.T) {
+		incoming := &model.Content{Title: "You won't believe this"}
+		keepLockedFieldsAndRescore(stored, incoming, scorer)
+		require.Equal(t, "fr", incoming.LangIsoCode)
+		require.Equal(t, 0.5, incoming.LangConfidence)
+	})
+}
+
+func TestUpdateSanitizesRawSummary(t *testing.T) {
+	stored := &model.Content{ID: "a1", ContentURL: "https://example.com/news/a1", RawSummary: `<p>Hi <a href="https://example.com/more" rel="noopener noreferrer">more</a></p>`}
+	updated := *stored
+	updated.RawSummary = `<p onclick="steal()">Hi <a href="/more">more</a></p><script>steal()</script>`
+
+	// Nothing left to change once sanitized, so no query is run
+	require.NoError(t, NewContentService(dryRunDB(t)).Update(stored, &updated, model.RevisionCauseManual))
+	require.Equal(t, stored.RawSummary, updated.RawSummary)
+}
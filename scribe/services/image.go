@@ -0,0 +1,335 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/skatekrak/scribe/internal/images"
+	"github.com/skatekrak/scribe/internal/storage"
+	"github.com/skatekrak/scribe/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Number of images downloaded and resized at the same time
+const imageWorkers = 4
+
+// Images bigger than this aren't mirrored
+const maxImageSize = 20 << 20
+
+var imageClient = &http.Client{Timeout: 15 * time.Second}
+
+// URLs that couldn't be mirrored aren't tried again before that
+const imageRetryDelay = time.Hour
+
+// Mirror the thumbnails and icons into the image storage. Without storage
+// nothing is mirrored and the original URLs are the only ones available.
+type ImageService struct {
+	db      *gorm.DB
+	storage storage.Storage
+
+	failuresMutex sync.Mutex
+	failures      map[string]time.Time // Last failure of the URLs
+}
+
+func NewImageService(db *gorm.DB, storage storage.Storage) *ImageService {
+	return &ImageService{db: db, storage: storage, failures: map[string]time.Time{}}
+}
+
+// Whether the URL failed to be mirrored recently, the failures too old are forgotten
+func (is *ImageService) failedRecently(url string) bool {
+	is.failuresMutex.Lock()
+	defer is.failuresMutex.Unlock()
+
+	failedAt, ok := is.failures[url]
+	if ok && time.Since(failedAt) >= imageRetryDelay {
+		delete(is.failures, url)
+		return false
+	}
+	return ok
+}
+
+func (is *ImageService) setFailed(url string) {
+	is.failuresMutex.Lock()
+	defer is.failuresMutex.Unlock()
+
+	is.failures[url] = time.Now()
+}
+
+// ImageID is the ID of the image mirrored from the URL
+func ImageID(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(hash[:16])
+}
+
+func imageKey(id string, derivative string) string {
+	return id + "/" + derivative
+}
+
+func (is *ImageService) Get(id string) (model.Image, error) {
+	var image model.Image
+	err := is.db.Where("id = ?", id).First(&image).Error
+	return image, err
+}
+
+// Data of a derivative of an image, with its content type
+func (is *ImageService) GetDerivative(image model.Image, derivative string) ([]byte, string, error) {
+	if is.storage == nil || !image.Derivatives.Has(derivative) {
+		return nil, "", storage.ErrNotFound
+	}
+	return is.storage.Get(imageKey(image.ID, derivative))
+}
+
+func (is *ImageService) download(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scribe/1.0; +https://github.com/skatekrak/scribe)")
+
+	response, err := imageClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching image: %s", response.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, images.ErrTooLarge
+	}
+
+	return data, nil
+}
+
+// Download the image, store its derivatives and save it
+func (is *ImageService) mirror(url string) (*model.Image, error) {
+	data, err := is.download(url)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := images.Process(data)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &model.Image{
+		ID:          ImageID(url),
+		SourceURL:   url,
+		Width:       result.Width,
+		Height:      result.Height,
+		BlurHash:    result.BlurHash,
+		Derivatives: model.FieldList{},
+	}
+
+	for _, derivative := range result.Derivatives {
+		if err := is.storage.Put(imageKey(image.ID, derivative.Name), derivative.Data, derivative.ContentType); err != nil {
+			return nil, err
+		}
+		image.Derivatives = append(image.Derivatives, derivative.Name)
+	}
+
+	err = is.db.Clauses(clause.OnConflict{DoNothing: true}).Create(image).Error
+	return image, err
+}
+
+// Mirror the images of the URLs that aren't mirrored yet. Returns the IDs of
+// the images available, by URL. Failures are only logged, and the URLs that
+// failed recently are skipped.
+func (is *ImageService) MirrorMany(urls []string) map[string]string {
+	mirrored := map[string]string{}
+	if is.storage == nil {
+		return mirrored
+	}
+
+	ids := []string{}
+	urlsByID := map[string]string{}
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		id := ImageID(url)
+		if _, ok := urlsByID[id]; !ok && !is.failedRecently(url) {
+			ids = append(ids, id)
+			urlsByID[id] = url
+		}
+	}
+
+	existing := []string{}
+	for start := 0; start < len(ids); start += lookupBatchSize {
+		end := start + lookupBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var found []string
+		if err := is.db.Model(&model.Image{}).Where("id IN ?", ids[start:end]).Pluck("id", &found).Error; err != nil {
+			log.Printf("Couldn't look up images: %s", err)
+			return mirrored
+		}
+		existing = append(existing, found...)
+	}
+
+	for _, id := range existing {
+		mirrored[urlsByID[id]] = id
+		delete(urlsByID, id)
+	}
+
+	queue := make(chan string)
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < imageWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for url := range queue {
+				image, err := is.mirror(url)
+				if err != nil {
+					log.Printf("Couldn't mirror image %s: %s", url, err)
+					is.setFailed(url)
+					continue
+				}
+
+				mutex.Lock()
+				mirrored[url] = image.ID
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, url := range urlsByID {
+		queue <- url
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return mirrored
+}
+
+// Mirror the thumbnails of the contents and link them. Returns the number of
+// contents whose thumbnail changed
+func (is *ImageService) MirrorContents(contents []*model.Content) int {
+	linked := 0
+	if is.storage == nil || len(contents) == 0 {
+		return linked
+	}
+
+	urls := make([]string, len(contents))
+	for i, content := range contents {
+		urls[i] = content.ThumbnailURL
+	}
+	mirrored := is.MirrorMany(urls)
+
+	for _, content := range contents {
+		if content.ID == "" {
+			continue
+		}
+
+		// Unlink the previous image when the new one couldn't be mirrored
+		var id *string
+		if mirroredID, ok := mirrored[content.ThumbnailURL]; ok {
+			id = &mirroredID
+		}
+
+		result := is.db.Model(&model.Content{}).
+			Where("id = ? AND thumbnail_image_id IS DISTINCT FROM ?", content.ID, id).
+			UpdateColumns(map[string]interface{}{"thumbnail_image_id": id, "updated_at": time.Now()})
+		if result.Error != nil {
+			log.Printf("Couldn't link thumbnail of content %s: %s", content.ID, result.Error)
+			continue
+		}
+		content.ThumbnailImageID = id
+		linked += int(result.RowsAffected)
+	}
+
+	return linked
+}
+
+// Mirror the icons of the sources and link them. Returns the number of sources
+// whose icon changed
+func (is *ImageService) MirrorSources(sources []*model.Source) int {
+	linked := 0
+	if is.storage == nil || len(sources) == 0 {
+		return linked
+	}
+
+	urls := make([]string, len(sources))
+	for i, source := range sources {
+		urls[i] = source.IconURL
+	}
+	mirrored := is.MirrorMany(urls)
+
+	for _, source := range sources {
+		if source.ID == 0 {
+			continue
+		}
+
+		// Unlink the previous image when the new one couldn't be mirrored
+		var id *string
+		if mirroredID, ok := mirrored[source.IconURL]; ok {
+			id = &mirroredID
+		}
+
+		result := is.db.Model(&model.Source{}).
+			Where("id = ? AND icon_image_id IS DISTINCT FROM ?", source.ID, id).
+			UpdateColumns(map[string]interface{}{"icon_image_id": id, "updated_at": time.Now()})
+		if result.Error != nil {
+			log.Printf("Couldn't link icon of source %d: %s", source.ID, result.Error)
+			continue
+		}
+		source.IconImageID = id
+		linked += int(result.RowsAffected)
+	}
+
+	return linked
+}
+
+// Mirror the thumbnails and icons changed since the given time that aren't
+// mirrored yet, and link them. Returns the number of contents and sources
+// whose image changed
+func (is *ImageService) MirrorPending(since time.Time, batchSize int) (int, int, error) {
+	linkedContents, linkedSources := 0, 0
+	if is.storage == nil {
+		return linkedContents, linkedSources, nil
+	}
+
+	var contents []*model.Content
+	err := is.db.Select("contents.id", "contents.thumbnail_url").
+		Joins("LEFT JOIN images ON images.id = contents.thumbnail_image_id").
+		Where("contents.updated_at >= ?", since).
+		Where("images.source_url IS DISTINCT FROM NULLIF(contents.thumbnail_url, '')").
+		FindInBatches(&contents, batchSize, func(tx *gorm.DB, batch int) error {
+			linkedContents += is.MirrorContents(contents)
+			return nil
+		}).Error
+	if err != nil {
+		return linkedContents, linkedSources, err
+	}
+
+	var sources []*model.Source
+	err = is.db.Select("sources.id", "sources.icon_url").
+		Joins("LEFT JOIN images ON images.id = sources.icon_image_id").
+		Where("sources.updated_at >= ?", since).
+		Where("images.source_url IS DISTINCT FROM NULLIF(sources.icon_url, '')").
+		FindInBatches(&sources, batchSize, func(tx *gorm.DB, batch int) error {
+			linkedSources += is.MirrorSources(sources)
+			return nil
+		}).Error
+	return linkedContents, linkedSources, err
+}
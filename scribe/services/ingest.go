@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/skatekrak/scribe/model"
+)
+
+// Ingest rules of a source, with the patterns compiled
+type ingestFilter struct {
+	rules           model.IngestRules
+	includePatterns []*regexp.Regexp
+	excludePatterns []*regexp.Regexp
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled[i] = re
+	}
+	return compiled, nil
+}
+
+// ValidateIngestRules checks every pattern of the rules compiles
+func ValidateIngestRules(rules model.IngestRules) error {
+	_, err := newIngestFilter(rules)
+	return err
+}
+
+func newIngestFilter(rules model.IngestRules) (*ingestFilter, error) {
+	includePatterns, err := compilePatterns(rules.IncludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	excludePatterns, err := compilePatterns(rules.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingestFilter{rules, includePatterns, excludePatterns}, nil
+}
+
+func containsKeyword(text string, keywords []string) (string, bool) {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+func matchPattern(text string, patterns []*regexp.Regexp) (string, bool) {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return re.String(), true
+		}
+	}
+	return "", false
+}
+
+// Why the content doesn't follow the rules, empty when it does
+func (f *ingestFilter) reject(content *model.Content, now time.Time) string {
+	rules := f.rules
+	text := content.Title + "\n" + content.Summary
+	lowerText := strings.ToLower(text)
+
+	// Unknown publication dates and durations don't break the rules
+	if rules.MaxAge > 0 && !content.PublishedAt.IsZero() && content.PublishedAt.Before(now.AddDate(0, 0, -rules.MaxAge)) {
+		return fmt.Sprintf("published more than %d days ago", rules.MaxAge)
+	}
+
+	if rules.MinDuration > 0 && content.Duration > 0 && content.Duration < rules.MinDuration {
+		return fmt.Sprintf("shorter than %d seconds", rules.MinDuration)
+	}
+
+	if keyword, ok := containsKeyword(lowerText, rules.ExcludeKeywords); ok {
+		return fmt.Sprintf("contains excluded keyword %q", keyword)
+	}
+
+	if pattern, ok := matchPattern(text, f.excludePatterns); ok {
+		return fmt.Sprintf("matches excluded pattern %q", pattern)
+	}
+
+	if len(rules.IncludeKeywords)+len(f.includePatterns) > 0 {
+		_, keyword := containsKeyword(lowerText, rules.IncludeKeywords)
+		_, pattern := matchPattern(text, f.includePatterns)
+
+		if !keyword && !pattern {
+			return "matches no included keyword or pattern"
+		}
+	}
+
+	return ""
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/skatekrak/scribe/model"
 	"gorm.io/gorm"
 )
@@ -33,6 +35,7 @@ func (s *LangService) Update(lang *model.Lang) error {
 	return s.db.Save(&lang).Error
 }
 
+// Soft deleted, and marked as updated for the sync
 func (s *LangService) Delete(lang *model.Lang) error {
-	return s.db.Delete(&lang).Error
+	return s.db.Model(lang).Update("deleted_at", time.Now()).Error
 }
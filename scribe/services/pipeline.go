@@ -0,0 +1,254 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/helpers"
+)
+
+// What a processor works on. Processors of a stage run before the ones of the next stage
+type Stage int
+
+const (
+	StageFetched Stage = iota // Fetched data, before it's mapped
+	StageMap                  // Mapping of the fetched data into a content
+	StageMapped               // Mapped content
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageFetched:
+		return "fetched"
+	case StageMap:
+		return "map"
+	case StageMapped:
+		return "mapped"
+	}
+	return fmt.Sprintf("stage %d", int(s))
+}
+
+// A fetched content going through the pipeline
+type ContentItem struct {
+	Data    fetchers.ContentFetchData
+	Source  *model.Source
+	Stored  *model.Content // Stored version of the content, nil for a new one
+	Content *model.Content // Set by the map processor
+
+	Rejection string // Set by a processor to drop the item, with the reason
+}
+
+// A step of the ingest pipeline, between fetch and save
+type ContentProcessor interface {
+	Name() string
+	Stage() Stage
+	// Process the items in place. Per item failures are handled by the
+	// processor, an error means the processor couldn't run at all.
+	Process(items []*ContentItem) error
+}
+
+// A fetched content that wasn't saved because a processor rejected it
+type RejectedContent struct {
+	ContentID string `json:"contentId"`
+	SourceID  uint   `json:"sourceId"`
+	Title     string `json:"title"`
+	Processor string `json:"processor"`
+	Reason    string `json:"reason"`
+} // @name RejectedContent
+
+type ProcessorConfig struct {
+	Name        string `json:"name" validate:"required"`
+	Enabled     bool   `json:"enabled"`
+	FailOnError bool   `json:"failOnError"` // Abort the refresh instead of going on without the processor
+} // @name ProcessorConfig
+
+// Processors run on the fetched contents, in order
+type PipelineConfig struct {
+	Processors []ProcessorConfig `json:"processors" validate:"dive"`
+} // @name PipelineConfig
+
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		Processors: []ProcessorConfig{
+			{Name: ProcessorEnrich, Enabled: true},
+			{Name: ProcessorMap, Enabled: true, FailOnError: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: ProcessorLang, Enabled: true},
+			{Name: ProcessorRelevance, Enabled: true},
+			{Name: ProcessorIngestRules, Enabled: true},
+			{Name: ProcessorDedupe, Enabled: true},
+		},
+	}
+}
+
+var (
+	processedItems = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scribe_processor_items_total",
+		Help: "Number of contents that went through an ingest processor",
+	}, []string{"processor"})
+	rejectedItems = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scribe_processor_rejected_total",
+		Help: "Number of contents rejected by an ingest processor",
+	}, []string{"processor"})
+	processorErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scribe_processor_errors_total",
+		Help: "Number of times an ingest processor failed",
+	}, []string{"processor"})
+	processorDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scribe_processor_duration_seconds",
+		Help:    "Time spent by an ingest processor on a batch of contents",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"processor"})
+)
+
+type pipelineStep struct {
+	processor ContentProcessor
+	config    ProcessorConfig
+}
+
+type Pipeline struct {
+	steps []pipelineStep
+}
+
+// Processors that can't be disabled: nothing is saved without map, and the
+// fetched HTML isn't safe to serve without sanitize
+var requiredProcessors = []string{ProcessorMap, ProcessorSanitize}
+
+// NewPipeline chains the enabled processors of the config. The required
+// processors have to be enabled, and the order has to follow the stages.
+func NewPipeline(config PipelineConfig, processors []ContentProcessor) (*Pipeline, error) {
+	available := map[string]ContentProcessor{}
+	for _, processor := range processors {
+		available[processor.Name()] = processor
+	}
+
+	pipeline := &Pipeline{}
+	seen := map[string]bool{}
+	enabled := map[string]bool{}
+	stage := StageFetched
+
+	for _, c := range config.Processors {
+		processor, ok := available[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown processor %s", c.Name)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("processor %s is listed twice", c.Name)
+		}
+		seen[c.Name] = true
+
+		if !c.Enabled {
+			continue
+		}
+
+		if processor.Stage() < stage {
+			return nil, fmt.Errorf("processor %s (stage %s) is listed after a processor of a later stage", c.Name, processor.Stage())
+		}
+		stage = processor.Stage()
+		enabled[c.Name] = true
+
+		pipeline.steps = append(pipeline.steps, pipelineStep{processor, c})
+	}
+
+	for _, name := range requiredProcessors {
+		if !enabled[name] {
+			return nil, fmt.Errorf("processor %s has to be enabled", name)
+		}
+	}
+
+	return pipeline, nil
+}
+
+// Run the items through every processor. Returns the items left and the
+// rejected ones.
+func (p *Pipeline) Run(items []*ContentItem) ([]*ContentItem, []RejectedContent, error) {
+	rejected := []RejectedContent{}
+	// IDs of the new contents rejected after being given one by dedupe
+	discarded := map[string]*string{}
+
+	for _, step := range p.steps {
+		name := step.processor.Name()
+		if len(items) == 0 {
+			break
+		}
+
+		start := time.Now()
+		err := step.processor.Process(items)
+		processorDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		processedItems.WithLabelValues(name).Add(float64(len(items)))
+
+		if err != nil {
+			processorErrors.WithLabelValues(name).Inc()
+			if step.config.FailOnError || helpers.Has(requiredProcessors, name) {
+				return nil, nil, fmt.Errorf("processor %s failed: %w", name, err)
+			}
+			log.Printf("Processor %s failed, going on without it: %s", name, err)
+		}
+
+		kept := items[:0]
+		for _, item := range items {
+			if item.Rejection == "" {
+				kept = append(kept, item)
+				continue
+			}
+
+			rejectedItems.WithLabelValues(name).Inc()
+			rejected = append(rejected, item.rejected(name))
+			if item.Stored == nil && item.Content != nil && item.Content.ID != "" {
+				discarded[item.Content.ID] = nil
+			}
+		}
+		items = kept
+	}
+
+	contents := make([]*model.Content, len(items))
+	for i, item := range items {
+		contents[i] = item.Content
+	}
+	redirectDuplicates(contents, discarded)
+
+	return items, rejected, nil
+}
+
+func (item *ContentItem) rejected(processor string) RejectedContent {
+	title := item.Data.Title
+	if item.Content != nil {
+		title = item.Content.Title
+	}
+
+	return RejectedContent{
+		ContentID: item.Data.ContentID,
+		SourceID:  item.Source.ID,
+		Title:     title,
+		Processor: processor,
+		Reason:    item.Rejection,
+	}
+}
+
+// The configured pipeline, or the default one
+func (s *ConfigService) GetPipelineConfig() (PipelineConfig, error) {
+	value, err := s.Get(IngestPipeline)
+	if err != nil || !value.Valid || value.String == "" {
+		return DefaultPipelineConfig(), err
+	}
+
+	var config PipelineConfig
+	err = json.Unmarshal([]byte(value.String), &config)
+	return config, err
+}
+
+func (s *ConfigService) SetPipelineConfig(config PipelineConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	value := string(data)
+	return s.Set(IngestPipeline, &value)
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcessor struct {
+	name  string
+	stage Stage
+	calls int
+	err   error
+	run   func(item *ContentItem)
+}
+
+func (p *fakeProcessor) Name() string { return p.name }
+func (p *fakeProcessor) Stage() Stage { return p.stage }
+
+func (p *fakeProcessor) Process(items []*ContentItem) error {
+	p.calls++
+	if p.err != nil {
+		return p.err
+	}
+	for _, item := range items {
+		if p.run != nil {
+			p.run(item)
+		}
+	}
+	return nil
+}
+
+func newItems(source *model.Source, titles ...string) []*ContentItem {
+	items := make([]*ContentItem, len(titles))
+	for i, title := range titles {
+		items[i] = &ContentItem{
+			Data:   fetchers.ContentFetchData{ContentID: title, Title: title, PublishedAt: time.Now()},
+			Source: source,
+		}
+	}
+	return items
+}
+
+func TestNewPipeline(t *testing.T) {
+	processors := newProcessors(nil, nil, nil)
+
+	t.Run("default config", func(t *testing.T) {
+		pipeline, err := NewPipeline(DefaultPipelineConfig(), processors)
+		require.NoError(t, err)
+		require.Len(t, pipeline.steps, len(processors))
+	})
+
+	t.Run("disabled processors are skipped", func(t *testing.T) {
+		pipeline, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorEnrich, Enabled: false},
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+		}}, processors)
+		require.NoError(t, err)
+		require.Len(t, pipeline.steps, 2)
+	})
+
+	t.Run("map is required", func(t *testing.T) {
+		_, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: false},
+			{Name: ProcessorSanitize, Enabled: true},
+		}}, processors)
+		require.Error(t, err)
+	})
+
+	t.Run("sanitize is required", func(t *testing.T) {
+		config := DefaultPipelineConfig()
+		for i := range config.Processors {
+			if config.Processors[i].Name == ProcessorSanitize {
+				config.Processors[i].Enabled = false
+			}
+		}
+
+		_, err := NewPipeline(config, processors)
+		require.ErrorContains(t, err, ProcessorSanitize)
+		require.Error(t, ValidatePipelineConfig(config))
+
+		_, err = NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+		}}, processors)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown processor", func(t *testing.T) {
+		_, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: "translate", Enabled: true},
+		}}, processors)
+		require.Error(t, err)
+	})
+
+	t.Run("stages out of order", func(t *testing.T) {
+		_, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorLang, Enabled: true},
+			{Name: ProcessorMap, Enabled: true},
+		}}, processors)
+		require.EqualError(t, err, "processor map (stage map) is listed after a processor of a later stage")
+
+		_, err = NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: ProcessorEnrich, Enabled: true},
+		}}, processors)
+		require.EqualError(t, err, "processor enrich (stage fetched) is listed after a processor of a later stage")
+	})
+}
+
+func TestPipelineRun(t *testing.T) {
+	source := &model.Source{Model: model.Model{ID: 1}, SourceType: "youtube"}
+
+	t.Run("rejected items leave the pipeline", func(t *testing.T) {
+		reject := &fakeProcessor{name: "reject", stage: StageMapped, run: func(item *ContentItem) {
+			if item.Content.Title == "ad" {
+				item.Rejection = "ad"
+			}
+		}}
+		after := &fakeProcessor{name: "after", stage: StageMapped, run: func(item *ContentItem) {
+			require.NotEqual(t, "ad", item.Content.Title)
+		}}
+
+		pipeline, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: "reject", Enabled: true},
+			{Name: "after", Enabled: true},
+		}}, []ContentProcessor{&mapProcessor{}, &sanitizeProcessor{}, reject, after})
+		require.NoError(t, err)
+
+		items, rejected, err := pipeline.Run(newItems(source, "kickflip", "ad"))
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, "kickflip", items[0].Content.Title)
+		require.Equal(t, []RejectedContent{{ContentID: "ad", SourceID: 1, Title: "ad", Processor: "reject", Reason: "ad"}}, rejected)
+	})
+
+	t.Run("duplicates of rejected items are redirected", func(t *testing.T) {
+		group := &fakeProcessor{name: "group", stage: StageMapped, run: func(item *ContentItem) {
+			item.Content.ID = item.Content.Title
+			if item.Content.Title != "first" {
+				first := "first"
+				item.Content.DuplicateOfID = &first
+			}
+		}}
+		reject := &fakeProcessor{name: "reject", stage: StageMapped, run: func(item *ContentItem) {
+			if item.Content.Title == "first" {
+				item.Rejection = "first"
+			}
+		}}
+
+		pipeline, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: "group", Enabled: true},
+			{Name: "reject", Enabled: true},
+		}}, []ContentProcessor{&mapProcessor{}, &sanitizeProcessor{}, group, reject})
+		require.NoError(t, err)
+
+		items, _, err := pipeline.Run(newItems(source, "first", "second", "third"))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Nil(t, items[0].Content.DuplicateOfID)
+		require.Equal(t, "second", *items[1].Content.DuplicateOfID)
+	})
+
+	t.Run("failing processor", func(t *testing.T) {
+		failing := &fakeProcessor{name: "failing", stage: StageMapped, err: errors.New("down")}
+		processors := []ContentProcessor{&mapProcessor{}, &sanitizeProcessor{}, failing}
+
+		pipeline, err := NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: "failing", Enabled: true},
+		}}, processors)
+		require.NoError(t, err)
+
+		items, _, err := pipeline.Run(newItems(source, "kickflip"))
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		pipeline, err = NewPipeline(PipelineConfig{Processors: []ProcessorConfig{
+			{Name: ProcessorMap, Enabled: true},
+			{Name: ProcessorSanitize, Enabled: true},
+			{Name: "failing", Enabled: true, FailOnError: true},
+		}}, processors)
+		require.NoError(t, err)
+
+		_, _, err = pipeline.Run(newItems(source, "kickflip"))
+		require.Error(t, err)
+	})
+}
+
+func TestSanitizeProcessor(t *testing.T) {
+	items := []*ContentItem{{Content: &model.Content{
+		RawSummary: `<p onclick="alert(1)">Hello</p><script>alert(1)</script>`,
+	}}}
+
+	require.NoError(t, (&sanitizeProcessor{}).Process(items))
+	require.Equal(t, "<p>Hello</p>", items[0].Content.RawSummary)
+}
+
+func TestIngestRulesProcessor(t *testing.T) {
+	source := &model.Source{
+		Model: model.Model{ID: 1},
+		IngestRules: model.IngestRules{
+			ExcludeKeywords: []string{"sponsored"},
+			ExcludePatterns: []string{`(?i)^merch drop`},
+			MinDuration:     30,
+			MaxAge:          7,
+		},
+	}
+
+	newItem := func(title string, duration int, publishedAt time.Time) *ContentItem {
+		return &ContentItem{Source: source, Content: &model.Content{Title: title, Duration: duration, PublishedAt: publishedAt}}
+	}
+
+	now := time.Now()
+	items := []*ContentItem{
+		newItem("Full part", 300, now),
+		newItem("This video is SPONSORED by", 300, now),
+		newItem("Merch drop today", 300, now),
+		newItem("Quick clip", 10, now),
+		newItem("Old part", 300, now.AddDate(0, 0, -30)),
+		newItem("Upcoming live", 0, now),
+		newItem("Undated article", 300, time.Time{}),
+	}
+	stored := newItem("Sponsored but stored", 300, now)
+	stored.Stored = &model.Content{}
+	items = append(items, stored)
+
+	require.NoError(t, (&ingestRulesProcessor{}).Process(items))
+
+	rejected := []string{}
+	for _, item := range items {
+		if item.Rejection != "" {
+			rejected = append(rejected, item.Content.Title)
+		}
+	}
+	require.Equal(t, []string{"This video is SPONSORED by", "Merch drop today", "Quick clip", "Old part"}, rejected)
+}
+
+func TestDuplicatePeriods(t *testing.T) {
+	published := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	contents := []*model.Content{
+		{PublishedAt: published},
+		{PublishedAt: published.AddDate(0, 0, 10)},
+		{PublishedAt: published.AddDate(1, 0, 0)},
+	}
+
+	require.Equal(t, []Period{
+		{published.Add(-duplicateWindow), published.AddDate(0, 0, 10).Add(duplicateWindow)},
+		{published.AddDate(1, 0, 0).Add(-duplicateWindow), published.AddDate(1, 0, 0).Add(duplicateWindow)},
+	}, duplicatePeriods(contents))
+}
+
+func TestDuplicateOf(t *testing.T) {
+	published := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	title := "Tyshawn Jones 'Hockey 4' Part"
+	canonicalID := "canonical"
+	candidates := []*model.Content{
+		{ID: "same-source", SourceID: 1, Title: title, PublishedAt: published},
+		{ID: "too-old", SourceID: 2, Title: title, PublishedAt: published.AddDate(0, -1, 0)},
+		{ID: "page", SourceID: 3, NormalizedURL: "example.com/hockey", PublishedAt: published.AddDate(-1, 0, 0)},
+		{ID: "duplicate", SourceID: 4, Title: title, DuplicateOfID: &canonicalID, PublishedAt: published.AddDate(0, 0, -2)},
+	}
+
+	t.Run("same title", func(t *testing.T) {
+		content := &model.Content{SourceID: 1, Title: title, PublishedAt: published}
+		require.Equal(t, &canonicalID, duplicateOf(content, candidates))
+	})
+
+	t.Run("only the same source", func(t *testing.T) {
+		content := &model.Content{SourceID: 1, Title: title, PublishedAt: published}
+		require.Nil(t, duplicateOf(content, candidates[:1]))
+	})
+
+	t.Run("outside of the window", func(t *testing.T) {
+		content := &model.Content{SourceID: 1, Title: title, PublishedAt: published}
+		require.Nil(t, duplicateOf(content, candidates[:2]))
+	})
+
+	t.Run("same page outside of the window", func(t *testing.T) {
+		content := &model.Content{SourceID: 1, NormalizedURL: "example.com/hockey", PublishedAt: published}
+		require.Equal(t, "page", *duplicateOf(content, candidates))
+	})
+}
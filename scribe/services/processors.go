@@ -0,0 +1,248 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/internal/dedup"
+	"github.com/skatekrak/scribe/internal/sanitize"
+	"github.com/skatekrak/scribe/model"
+)
+
+// Names of the ingest processors, used in the pipeline config
+const (
+	ProcessorEnrich      = "enrich"
+	ProcessorMap         = "map"
+	ProcessorSanitize    = "sanitize"
+	ProcessorLang        = "lang"
+	ProcessorRelevance   = "relevance"
+	ProcessorIngestRules = "ingestRules"
+	ProcessorDedupe      = "dedupe"
+)
+
+// How far apart two duplicates can be published
+const duplicateWindow = 7 * 24 * time.Hour
+
+// Every available processor, in their default order
+func newProcessors(fetcher *fetchers.Fetcher, config *ConfigService, cs *ContentService) []ContentProcessor {
+	return []ContentProcessor{
+		&enrichProcessor{fetcher},
+		&mapProcessor{},
+		&sanitizeProcessor{},
+		&langProcessor{},
+		&relevanceProcessor{config},
+		&ingestRulesProcessor{},
+		&dedupeProcessor{cs},
+	}
+}
+
+// ValidatePipelineConfig checks the pipeline can be built from the config
+func ValidatePipelineConfig(config PipelineConfig) error {
+	_, err := NewPipeline(config, newProcessors(nil, nil, nil))
+	return err
+}
+
+// Fill what the feed didn't give for the new articles, from their page
+type enrichProcessor struct {
+	fetcher *fetchers.Fetcher
+}
+
+func (p *enrichProcessor) Name() string { return ProcessorEnrich }
+func (p *enrichProcessor) Stage() Stage { return StageFetched }
+
+func (p *enrichProcessor) Process(items []*ContentItem) error {
+	articles := []*fetchers.ContentFetchData{}
+	for _, item := range items {
+		if item.Stored == nil && item.Source.SourceType == "rss" {
+			articles = append(articles, &item.Data)
+		}
+	}
+
+	p.fetcher.EnrichArticles(articles)
+	return nil
+}
+
+// Map the fetched data into a content
+type mapProcessor struct{}
+
+func (p *mapProcessor) Name() string { return ProcessorMap }
+func (p *mapProcessor) Stage() Stage { return StageMap }
+
+func (p *mapProcessor) Process(items []*ContentItem) error {
+	for _, item := range items {
+		item.Content = formatContent(item.Data, item.Source)
+	}
+	return nil
+}
+
+// Keep only the allowed HTML of the raw summary and content
+type sanitizeProcessor struct{}
+
+func (p *sanitizeProcessor) Name() string { return ProcessorSanitize }
+func (p *sanitizeProcessor) Stage() Stage { return StageMapped }
+
+func (p *sanitizeProcessor) Process(items []*ContentItem) error {
+	for _, item := range items {
+		item.Content.RawSummary = sanitize.HTML(item.Content.RawSummary, item.Content.ContentURL)
+		item.Content.RawContent = sanitize.HTML(item.Content.RawContent, item.Content.ContentURL)
+	}
+	return nil
+}
+
+// Detect the lang of the content
+type langProcessor struct{}
+
+func (p *langProcessor) Name() string { return ProcessorLang }
+func (p *langProcessor) Stage() Stage { return StageMapped }
+
+func (p *langProcessor) Process(items []*ContentItem) error {
+	for _, item := range items {
+		detectLang(item.Content)
+	}
+	return nil
+}
+
+// Score how much the content is about skateboarding
+type relevanceProcessor struct {
+	config *ConfigService
+}
+
+func (p *relevanceProcessor) Name() string { return ProcessorRelevance }
+func (p *relevanceProcessor) Stage() Stage { return StageMapped }
+
+func (p *relevanceProcessor) Process(items []*ContentItem) error {
+	scorer, err := p.config.RelevanceScorer()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		scoreRelevance(scorer, item.Content, item.Source.SkateSource)
+	}
+	return nil
+}
+
+// Reject the new contents not following the ingest rules of their source.
+// Stored contents were accepted before and are kept.
+type ingestRulesProcessor struct{}
+
+func (p *ingestRulesProcessor) Name() string { return ProcessorIngestRules }
+func (p *ingestRulesProcessor) Stage() Stage { return StageMapped }
+
+func (p *ingestRulesProcessor) Process(items []*ContentItem) error {
+	filters := map[uint]*ingestFilter{}
+	now := time.Now()
+
+	for _, item := range items {
+		if item.Stored != nil {
+			continue
+		}
+
+		filter, ok := filters[item.Source.ID]
+		if !ok {
+			var err error
+			if filter, err = newIngestFilter(item.Source.IngestRules); err != nil {
+				log.Printf("Ignoring the ingest rules of source %d: %s", item.Source.ID, err)
+			}
+			filters[item.Source.ID] = filter
+		}
+
+		if filter != nil {
+			item.Rejection = filter.reject(item.Content, now)
+		}
+	}
+
+	return nil
+}
+
+// Group the new contents with the stored or new ones they duplicate, under
+// the first content of the group
+type dedupeProcessor struct {
+	cs *ContentService
+}
+
+func (p *dedupeProcessor) Name() string { return ProcessorDedupe }
+func (p *dedupeProcessor) Stage() Stage { return StageMapped }
+
+func (p *dedupeProcessor) Process(items []*ContentItem) error {
+	contents := []*model.Content{}
+	for _, item := range items {
+		if item.Stored == nil {
+			contents = append(contents, item.Content)
+		}
+	}
+	if len(contents) == 0 {
+		return nil
+	}
+
+	// The earliest content becomes the canonical one
+	sort.SliceStable(contents, func(i, j int) bool {
+		return contents[i].PublishedAt.Before(contents[j].PublishedAt)
+	})
+
+	urls := []string{}
+	for _, content := range contents {
+		setFingerprint(content)
+		if content.NormalizedURL != "" {
+			urls = append(urls, content.NormalizedURL)
+		}
+		// Needed to be referenced by the other contents of the batch
+		content.ID = uuid.NewString()
+	}
+
+	candidates, err := p.cs.FindDuplicateCandidates(urls, duplicatePeriods(contents))
+	if err != nil {
+		return err
+	}
+
+	for _, content := range contents {
+		content.DuplicateOfID = duplicateOf(content, candidates)
+		candidates = append(candidates, content)
+	}
+
+	return nil
+}
+
+// Periods around the publication of the contents, sorted by it, where their
+// duplicates can be. The overlapping ones are merged
+func duplicatePeriods(contents []*model.Content) []Period {
+	periods := []Period{}
+	for _, content := range contents {
+		from := content.PublishedAt.Add(-duplicateWindow)
+		to := content.PublishedAt.Add(duplicateWindow)
+
+		if last := len(periods) - 1; last >= 0 && !from.After(periods[last].To) {
+			periods[last].To = to
+			continue
+		}
+		periods = append(periods, Period{from, to})
+	}
+	return periods
+}
+
+// ID of the canonical content of the first candidate the content duplicates.
+// Only the same page is looked for outside of the window around its publication
+func duplicateOf(content *model.Content, candidates []*model.Content) *string {
+	for _, candidate := range candidates {
+		gap := content.PublishedAt.Sub(candidate.PublishedAt)
+		if gap < -duplicateWindow || gap > duplicateWindow {
+			if content.NormalizedURL == "" || content.NormalizedURL != candidate.NormalizedURL {
+				continue
+			}
+		}
+
+		if !dedup.IsDuplicate(fingerprint(content), fingerprint(candidate)) {
+			continue
+		}
+
+		canonicalID := candidate.ID
+		if candidate.DuplicateOfID != nil {
+			canonicalID = *candidate.DuplicateOfID
+		}
+		return &canonicalID
+	}
+	return nil
+}
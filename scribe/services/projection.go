@@ -0,0 +1,201 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/skatekrak/scribe/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Named sets of the content fields given by the lists
+const (
+	ProjectionCard = "card" // What a feed shows, the default. Gives model.ContentCard
+	ProjectionFull = "full" // Every field, like a single content
+)
+
+var ErrUnknownField = errors.New("unknown content field")
+
+// Always selected, the lists are ordered by them
+var keyColumns = []string{"id", "published_at", "created_at"}
+
+// Columns of the relations, by their JSON name
+var relationColumns = map[string]string{
+	"source":         "source_id",
+	"thumbnailImage": "thumbnail_image_id",
+	"iconImage":      "icon_image_id",
+}
+
+var (
+	columnsOnce          sync.Once
+	contentColumnNames   map[string]string
+	cardFields           []string
+	compactSourceColumns []string
+)
+
+// Column of each field of the model, by its JSON name
+func jsonColumns(value interface{}) map[string]string {
+	columns := map[string]string{}
+
+	s, err := schema.Parse(value, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(err)
+	}
+	for _, field := range s.Fields {
+		name := jsonName(field.Tag)
+		if name != "" && name != "-" && field.DBName != "" {
+			columns[name] = field.DBName
+		}
+	}
+	for name, column := range relationColumns {
+		if _, ok := s.FieldsByDBName[column]; ok {
+			columns[name] = column
+		}
+	}
+	return columns
+}
+
+func jsonName(tag reflect.StructTag) string {
+	return strings.Split(tag.Get("json"), ",")[0]
+}
+
+// JSON names of the fields of the type
+func jsonFields(t reflect.Type) []string {
+	fields := make([]string, t.NumField())
+	for i := range fields {
+		fields[i] = jsonName(t.Field(i).Tag)
+	}
+	return fields
+}
+
+func loadColumns() {
+	columnsOnce.Do(func() {
+		contentColumnNames = jsonColumns(&model.Content{})
+		cardFields = jsonFields(reflect.TypeOf(model.ContentCard{}))
+
+		sourceColumns := jsonColumns(&model.Source{})
+		for _, field := range jsonFields(reflect.TypeOf(model.CompactSource{})) {
+			compactSourceColumns = append(compactSourceColumns, sourceColumns[field])
+		}
+	})
+}
+
+// Content fields a list gives
+type Projection struct {
+	card   bool            // Given as model.ContentCard
+	fields map[string]bool // JSON names, nil for every field
+}
+
+// Projection of the fields when some are given, or else of the named one, card by default
+func NewProjection(name string, fields []string) (Projection, error) {
+	loadColumns()
+
+	p := Projection{}
+	if len(fields) == 0 {
+		if name == ProjectionFull {
+			return p, nil
+		}
+		p.card = true
+		fields = cardFields
+	}
+
+	// Always given, to tell the contents apart
+	p.fields = map[string]bool{"id": true}
+	for _, field := range fields {
+		if _, ok := contentColumnNames[field]; !ok {
+			return p, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+		p.fields[field] = true
+	}
+	return p, nil
+}
+
+func (p Projection) has(field string) bool {
+	return p.fields == nil || p.fields[field]
+}
+
+// Select the columns of the fields, and load the relations they need. Not a
+// scope, the counts of the paginations need the selected columns beforehand
+func (p Projection) apply(tx *gorm.DB) *gorm.DB {
+	if p.fields == nil {
+		return tx.Joins("Source").Preload("Source.IconImage").Preload("ThumbnailImage")
+	}
+
+	if p.has("source") {
+		// Only the columns of the compact source, a join would select them all
+		tx = tx.Preload("Source", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select(compactSourceColumns)
+		}).Preload("Source.IconImage")
+	}
+	if p.has("thumbnailImage") {
+		tx = tx.Preload("ThumbnailImage")
+	}
+
+	return tx.Select(p.columns())
+}
+
+// Columns of the fields, qualified
+func (p Projection) columns() []string {
+	selected := map[string]bool{}
+	columns := []string{}
+	add := func(column string) {
+		if !selected[column] {
+			selected[column] = true
+			columns = append(columns, "contents."+column)
+		}
+	}
+
+	for _, column := range keyColumns {
+		add(column)
+	}
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		add(contentColumnNames[field])
+	}
+	return columns
+}
+
+// The content with only the fields of the projection: the content itself, its
+// card, or a JSON object of the fields
+func (p Projection) Project(c model.Content) (interface{}, error) {
+	if p.fields == nil {
+		return c, nil
+	}
+	if p.card {
+		return c.Card(), nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	for name := range fields {
+		if !p.fields[name] {
+			delete(fields, name)
+		}
+	}
+
+	if p.fields["source"] {
+		if fields["source"], err = json.Marshal(c.Source.Compact()); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
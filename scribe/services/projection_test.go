@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjection(t *testing.T) {
+	content := model.Content{
+		ID:         "a1",
+		Title:      "Kickflip",
+		RawContent: "<p>Kickflip</p>",
+		Source:     model.Source{Title: "Thrasher", Description: "Skate and destroy", Lang: model.Lang{IsoCode: "en"}},
+	}
+
+	keys := func(v interface{}) map[string]interface{} {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &fields))
+		return fields
+	}
+
+	t.Run("card by default", func(t *testing.T) {
+		card, err := NewProjection("", nil)
+		require.NoError(t, err)
+
+		projected, err := card.Project(content)
+		require.NoError(t, err)
+		require.IsType(t, model.ContentCard{}, projected)
+
+		fields := keys(projected)
+		require.Equal(t, "Kickflip", fields["title"])
+		require.NotContains(t, fields, "rawContent")
+
+		source := fields["source"].(map[string]interface{})
+		require.Equal(t, "Thrasher", source["title"])
+		require.NotContains(t, source, "description")
+		require.NotContains(t, source, "lang")
+	})
+
+	t.Run("full", func(t *testing.T) {
+		full, err := NewProjection(ProjectionFull, nil)
+		require.NoError(t, err)
+		projected, err := full.Project(content)
+		require.NoError(t, err)
+		require.Equal(t, content, projected)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		projection, err := NewProjection(ProjectionFull, []string{"title", "rawContent"})
+		require.NoError(t, err)
+
+		projected, err := projection.Project(content)
+		require.NoError(t, err)
+
+		fields := keys(projected)
+		require.Len(t, fields, 3)
+		require.Equal(t, "a1", fields["id"])
+		require.Equal(t, "<p>Kickflip</p>", fields["rawContent"])
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := NewProjection("", []string{"title", "revisions"})
+		require.ErrorIs(t, err, ErrUnknownField)
+	})
+}
+
+func TestProjectionSelect(t *testing.T) {
+	db := dryRunDB(t)
+	sql := lastQuery(t, db)
+
+	t.Run("card", func(t *testing.T) {
+		card, err := NewProjection("", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, card.apply(db.Model(&model.Content{})).Find(&[]model.Content{}).Error)
+		require.Contains(t, *sql, "SELECT contents.id,contents.published_at,contents.created_at,")
+		require.Contains(t, *sql, "contents.source_id")
+		require.NotContains(t, *sql, "contents.raw_content")
+		require.NotContains(t, *sql, "JOIN")
+
+		// Of the preloaded sources
+		require.Equal(t, []string{"id", "source_type", "title", "short_title", "icon_url", "icon_image_id", "website_url", "skate_source"}, compactSourceColumns)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		projection, err := NewProjection("", []string{"title"})
+		require.NoError(t, err)
+
+		require.NoError(t, projection.apply(db.Model(&model.Content{})).Find(&[]model.Content{}).Error)
+		require.Contains(t, *sql, `SELECT contents.id,contents.published_at,contents.created_at,contents.title FROM "contents"`)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		full, err := NewProjection(ProjectionFull, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, full.apply(db.Model(&model.Content{})).Find(&[]model.Content{}).Error)
+		require.Contains(t, *sql, `LEFT JOIN "sources" "Source"`)
+	})
+}
@@ -17,6 +17,46 @@ type RefreshErrors struct {
 	Errors  map[string]error `json:"errors,omitempty"`
 }
 
+// Stored and incoming value of a content field changed by a refresh
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+} // @name FieldChange
+
+type SourceChange struct {
+	ID       uint                   `json:"id"`
+	SourceID string                 `json:"sourceId"`
+	Fields   map[string]FieldChange `json:"fields"`
+} // @name SourceChange
+
+type ContentChange struct {
+	ID        string                 `json:"id"`
+	ContentID string                 `json:"contentId"`
+	Fields    map[string]FieldChange `json:"fields"`
+} // @name ContentChange
+
+// What a refresh added or changed. With DryRun nothing has been written.
+type RefreshDiff struct {
+	DryRun           bool              `json:"dryRun"`
+	NewContents      []*model.Content  `json:"newContents"`
+	UpdatedContents  []ContentChange   `json:"updatedContents"`
+	NewSources       []*model.Source   `json:"newSources"`
+	UpdatedSources   []SourceChange    `json:"updatedSources"`
+	Rejected         int               `json:"rejected"` // New contents not following the ingest rules of their source
+	RejectedContents []RejectedContent `json:"rejectedContents"`
+} // @name RefreshDiff
+
+func newRefreshDiff(dryRun bool) *RefreshDiff {
+	return &RefreshDiff{
+		DryRun:           dryRun,
+		NewContents:      []*model.Content{},
+		UpdatedContents:  []ContentChange{},
+		NewSources:       []*model.Source{},
+		UpdatedSources:   []SourceChange{},
+		RejectedContents: []RejectedContent{},
+	}
+}
+
 type RefreshService struct {
 	fetcher          *fetchers.Fetcher
 	feedlyCategoryID string
@@ -35,17 +75,35 @@ func NewRefreshService(db *gorm.DB, fetcher *fetchers.Fetcher, feedlyCategoryID
 	}
 }
 
-func (rs *RefreshService) RefreshByTypes(types []string) ([]*model.Content, error) {
+// Pipeline of the configured processors
+func (rs *RefreshService) newPipeline() (*Pipeline, error) {
+	config, err := rs.config.GetPipelineConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPipeline(config, newProcessors(rs.fetcher, rs.config, rs.cs))
+}
+
+// A fetched content along with the source it belongs to
+type fetchedContent struct {
+	data   fetchers.ContentFetchData
+	source *model.Source
+}
+
+// Fetch new contents of every source of the given types. With dryRun the
+// contents are fetched and mapped but nothing is saved.
+func (rs *RefreshService) RefreshByTypes(types []string, dryRun bool) (*RefreshDiff, error) {
 	sources, err := rs.ss.FindAll(types)
 	if err != nil {
-		return []*model.Content{}, err
+		return nil, err
 	}
 
 	if len(sources) <= 0 {
-		return []*model.Content{}, errors.New("no sources to update")
+		return nil, errors.New("no sources to update")
 	}
 
-	formattedContents := []*model.Content{}
+	fetched := []fetchedContent{}
 	errs := make(map[uint]error)
 	now := time.Now()
 
@@ -59,12 +117,7 @@ func (rs *RefreshService) RefreshByTypes(types []string) ([]*model.Content, erro
 			}
 
 			for _, content := range contents {
-				// Only add content not already here
-				if _, err := rs.cs.FindOneByContentID(content.ContentID); err != nil {
-					if errors.Is(err, gorm.ErrRecordNotFound) {
-						formattedContents = append(formattedContents, formatContent(content, source))
-					}
-				}
+				fetched = append(fetched, fetchedContent{data: content, source: source})
 			}
 
 			source.RefreshedAt = &now
@@ -73,110 +126,273 @@ func (rs *RefreshService) RefreshByTypes(types []string) ([]*model.Content, erro
 
 	if helpers.Has(types, "rss") {
 		if err := rs.refreshAndSaveFeedlyTokenIfNeeded(); err != nil {
-			return []*model.Content{}, err
+			return nil, err
 		}
 		contents, err := rs.fetcher.FetchFeedlyContents(rs.feedlyCategoryID)
 		if err != nil {
-			return []*model.Content{}, err
+			return nil, err
 		}
 
 		for _, content := range contents {
-			// Only add content not already here
-			if _, err := rs.cs.FindOneByContentID(content.ContentID); err != nil {
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					// Look into sources we've aleady fetched
-					source, ok := helpers.Find(sources, func(s *model.Source) bool {
-						return s.SourceID == content.SourceID
-					})
-
-					if ok {
-						formattedContents = append(formattedContents, formatContent(content, source))
-
-						source.RefreshedAt = &now
-					}
-				}
+			// Look into sources we've aleady fetched
+			source, ok := helpers.Find(sources, func(s *model.Source) bool {
+				return s.SourceID == content.SourceID
+			})
+
+			if ok {
+				fetched = append(fetched, fetchedContent{data: content, source: source})
+				source.RefreshedAt = &now
 			}
 		}
 	}
 
 	if len(errs) > 0 {
-		return []*model.Content{}, errors.New("errors fetching contents for some video channels")
+		return nil, errors.New("errors fetching contents for some video channels")
+	}
+
+	contentIDs := make([]string, len(fetched))
+	for i, f := range fetched {
+		contentIDs[i] = f.data.ContentID
+	}
+
+	stored, err := rs.cs.FindByContentIDs(contentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := rs.newPipeline()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rs.cs.AddMany(formattedContents, sources); err != nil {
-		return []*model.Content{}, err
+	items := []*ContentItem{}
+	seen := map[string]bool{}
+	for _, f := range fetched {
+		// Only add content not already here, once
+		if _, ok := stored[f.data.ContentID]; !ok && !seen[f.data.ContentID] {
+			seen[f.data.ContentID] = true
+			items = append(items, &ContentItem{Data: f.data, Source: f.source})
+		}
+	}
+
+	items, rejected, err := pipeline.Run(items)
+	if err != nil {
+		return nil, err
 	}
 
-	return formattedContents, nil
+	formattedContents := make([]*model.Content, len(items))
+	for i, item := range items {
+		formattedContents[i] = item.Content
+	}
+
+	diff := newRefreshDiff(dryRun)
+	diff.RejectedContents = rejected
+	diff.Rejected = len(rejected)
+
+	if dryRun {
+		diff.NewContents = formattedContents
+		return diff, nil
+	}
+
+	result, err := rs.cs.AddMany(formattedContents, sources, model.RevisionCauseRefresh)
+	if err != nil {
+		return nil, err
+	}
+	diff.NewContents = result.Inserted
+
+	return diff, nil
 }
 
-func (rs *RefreshService) RefreshBySource(source model.Source, force bool) ([]*model.Content, *RefreshErrors) {
+// Fetch the contents of a video source. Existing contents are only updated
+// with force. With dryRun nothing is saved.
+func (rs *RefreshService) RefreshBySource(source model.Source, force bool, dryRun bool) (*RefreshDiff, *RefreshErrors) {
 
 	if source.SourceType == "rss" {
-		return []*model.Content{}, &RefreshErrors{Error: errors.New("rss sources cannot be individually refreshed")}
+		return nil, &RefreshErrors{Error: errors.New("rss sources cannot be individually refreshed")}
 	}
 
 	contentsMap := map[string][]fetchers.ContentFetchData{}
 	if source.SourceType == "youtube" {
 		if errors := rs.fetcher.FetchYoutubeContent([]string{source.SourceID}, contentsMap); len(errors) > 0 {
-			return []*model.Content{}, &RefreshErrors{Errors: errors}
+			return nil, &RefreshErrors{Errors: errors}
 		}
 	} else if source.SourceType == "vimeo" {
 		if errors := rs.fetcher.FetcherVimeoContent([]string{source.SourceID}, contentsMap); len(errors) > 0 {
-			return []*model.Content{}, &RefreshErrors{Errors: errors}
+			return nil, &RefreshErrors{Errors: errors}
 		}
 	} else {
-		return []*model.Content{}, &RefreshErrors{Error: errors.New("Oops")}
+		return nil, &RefreshErrors{Error: errors.New("Oops")}
+	}
+
+	contents := contentsMap[source.SourceID]
+	contentIDs := make([]string, len(contents))
+	for i, content := range contents {
+		contentIDs[i] = content.ContentID
 	}
 
+	stored, err := rs.cs.FindByContentIDs(contentIDs)
+	if err != nil {
+		return nil, &RefreshErrors{Error: err}
+	}
+
+	pipeline, err := rs.newPipeline()
+	if err != nil {
+		return nil, &RefreshErrors{Error: err}
+	}
+
+	items := []*ContentItem{}
+	for _, content := range contents {
+		foundContent, ok := stored[content.ContentID]
+
+		if !ok {
+			items = append(items, &ContentItem{Data: content, Source: &source})
+		} else if force {
+			//It exists but we force the update
+			items = append(items, &ContentItem{Data: content, Source: &source, Stored: &foundContent})
+		}
+	}
+
+	items, rejected, err := pipeline.Run(items)
+	if err != nil {
+		return nil, &RefreshErrors{Error: err}
+	}
+
+	diff := newRefreshDiff(dryRun)
+	diff.RejectedContents = rejected
+	diff.Rejected = len(rejected)
 	formattedContents := []*model.Content{}
 
-	for _, content := range contentsMap[source.SourceID] {
-		foundContent, err := rs.cs.FindOneByContentID(content.ContentID)
+	for _, item := range items {
+		formattedContent := item.Content
+		formattedContents = append(formattedContents, formattedContent)
 
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				formattedContents = append(formattedContents, formatContent(content, &source))
-				continue
-			} else {
-				continue
-			}
+		if item.Stored == nil {
+			diff.NewContents = append(diff.NewContents, formattedContent)
+			continue
 		}
 
-		if force {
-			//It exists but we force the update
-			formattedContent := formatContent(content, &source)
-			formattedContent.ID = foundContent.ID
-			formattedContents = append(formattedContents, formattedContent)
+		formattedContent.ID = item.Stored.ID
+		keepLockedFields(item.Stored, formattedContent, source.LockedContentFields)
+
+		if fields := diffContent(*item.Stored, formattedContent); len(fields) > 0 {
+			diff.UpdatedContents = append(diff.UpdatedContents, ContentChange{
+				ID:        item.Stored.ID,
+				ContentID: item.Stored.ContentID,
+				Fields:    fields,
+			})
 		}
 	}
 
+	if dryRun {
+		return diff, nil
+	}
+
 	now := time.Now()
 	source.RefreshedAt = &now
 
-	if err := rs.cs.AddMany(formattedContents, []*model.Source{&source}); err != nil {
-		return []*model.Content{}, &RefreshErrors{Error: err}
+	cause := model.RevisionCauseRefresh
+	if force {
+		cause = model.RevisionCauseForce
 	}
 
-	return formattedContents, nil
+	result, err := rs.cs.AddMany(formattedContents, []*model.Source{&source}, cause)
+	if err != nil {
+		return nil, &RefreshErrors{Error: err}
+	}
+	diff.NewContents = result.Inserted
+
+	return diff, nil
 }
 
-func (rs *RefreshService) RefreshFeedlySource() ([]*model.Source, error) {
+// Add the feedly sources missing in Scribe and fill the empty unlocked fields
+// of the existing ones. With dryRun they are only listed.
+func (rs *RefreshService) RefreshFeedlySource(dryRun bool) (*RefreshDiff, error) {
 	if err := rs.refreshAndSaveFeedlyTokenIfNeeded(); err != nil {
-		return []*model.Source{}, err
+		return nil, err
 	}
 
 	data, err := rs.fetcher.FetchFeedlySources(rs.feedlyCategoryID)
 	if err != nil {
-		return []*model.Source{}, err
+		return nil, err
 	}
 
 	nextOrder, err := rs.ss.GetNextOrder()
 	if err != nil {
-		return []*model.Source{}, err
+		return nil, err
+	}
+
+	diff := newRefreshDiff(dryRun)
+
+	updatedSources, changes, err := rs.ss.ChannelUpdates(data)
+	if err != nil {
+		return nil, err
+	}
+	diff.UpdatedSources = changes
+
+	if dryRun {
+		if diff.NewSources, err = rs.ss.NewSources(data, "rss", nextOrder); err != nil {
+			return nil, err
+		}
+		return diff, nil
+	}
+
+	if err := rs.ss.UpdateMany(updatedSources); err != nil {
+		return nil, err
+	}
+
+	if diff.NewSources, err = rs.ss.AddManyIfNotExist(data, "rss", nextOrder); err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// Fetch again the statistics of the videos published in the given period,
+// returns the number of updated videos
+func (rs *RefreshService) RefreshVideoStats(period time.Duration) (int, error) {
+	contents, err := rs.cs.FindVideosPublishedSince(time.Now().Add(-period))
+	if err != nil {
+		return 0, err
+	}
+
+	return rs.refreshVideoDetails(contents)
+}
+
+// Fetch again the details of upcoming and ongoing live streams and premieres,
+// to know when they start or end. Returns the number of updated videos.
+func (rs *RefreshService) RefreshLiveVideos() (int, error) {
+	contents, err := rs.cs.FindVideosBySubTypes([]string{fetchers.SubTypeUpcoming, fetchers.SubTypeLive, fetchers.SubTypePremiere})
+	if err != nil {
+		return 0, err
+	}
+
+	return rs.refreshVideoDetails(contents)
+}
+
+func (rs *RefreshService) refreshVideoDetails(contents []model.Content) (int, error) {
+	videoIDs := map[string][]string{}
+	for _, content := range contents {
+		videoIDs[content.Source.SourceType] = append(videoIDs[content.Source.SourceType], content.ContentID)
+	}
+
+	updated := 0
+	for sourceType, ids := range videoIDs {
+		details, err := rs.fetcher.FetchVideosDetails(ids, sourceType)
+		if err != nil {
+			return updated, err
+		}
+
+		for _, content := range contents {
+			if d, ok := details[content.ContentID]; ok && content.Source.SourceType == sourceType {
+				if err := rs.cs.UpdateDetails(content, d); err != nil {
+					return updated, err
+				}
+				updated++
+			}
+		}
 	}
 
-	return rs.ss.AddManyIfNotExist(data, "rss", nextOrder)
+	return updated, nil
 }
 
 func (rs *RefreshService) refreshAndSaveFeedlyTokenIfNeeded() error {
@@ -238,21 +454,72 @@ func (rs *RefreshService) refreshAndSaveFeedlyToken() (string, error) {
 	return t, nil
 }
 
+// Compare the fields overwritten by AddMany
+func diffContent(stored model.Content, incoming *model.Content) map[string]FieldChange {
+	fields := map[string]FieldChange{}
+
+	for _, revision := range contentRevisions(&stored, incoming, "") {
+		fields[revision.Field] = FieldChange{Old: revision.OldValue, New: revision.NewValue}
+	}
+
+	return fields
+}
+
 func formatContent(content fetchers.ContentFetchData, source *model.Source) *model.Content {
 	contentType := "video"
 	if source.SourceType == "rss" {
 		contentType = "article"
 	}
 
+	var statsUpdatedAt *time.Time
+	if content.Duration > 0 || content.ViewCount != nil {
+		now := time.Now()
+		statsUpdatedAt = &now
+	}
+
+	subType := content.SubType
+	if subType == "" {
+		subType = fetchers.SubTypeRegular
+	}
+
+	// Live streams and premieres are listed with the time they actually started at
+	publishedAt := content.PublishedAt
+	if content.StartedAt != nil {
+		publishedAt = *content.StartedAt
+	}
+
+	// Keywords of the articles are kept as tags
+	tags := content.Tags
+	if len(tags) == 0 {
+		tags = content.Keywords
+	}
+
+	var author *string
+	if content.Author != "" {
+		author = &content.Author
+	}
+
 	return &model.Content{
 		SourceID:     source.ID,
 		ContentID:    content.ContentID,
-		PublishedAt:  content.PublishedAt,
+		PublishedAt:  publishedAt,
 		Title:        content.Title,
 		ThumbnailURL: content.ThumbnailURL,
 		ContentURL:   content.ContentURL,
+		CanonicalURL: content.CanonicalURL,
+		Author:       author,
 		RawSummary:   content.RawDescription,
 		Summary:      content.Description,
+		RawContent:   content.RawContent,
+		Content:      content.Content,
 		Type:         contentType,
+		SubType:      subType,
+
+		Duration:       content.Duration,
+		ViewCount:      content.ViewCount,
+		LikeCount:      content.LikeCount,
+		Tags:           tags,
+		Definition:     content.Definition,
+		StatsUpdatedAt: statsUpdatedAt,
 	}
 }
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/skatekrak/scribe/internal/relevance"
+	"github.com/skatekrak/scribe/model"
+)
+
+// The configured relevance dictionary, or the default one
+func (s *ConfigService) GetRelevanceDictionary() (relevance.Dictionary, error) {
+	value, err := s.Get(RelevanceDictionary)
+	if err != nil || !value.Valid || value.String == "" {
+		return relevance.DefaultDictionary(), err
+	}
+
+	var dictionary relevance.Dictionary
+	err = json.Unmarshal([]byte(value.String), &dictionary)
+	return dictionary, err
+}
+
+// Replace the relevance dictionary. Stored contents keep their score until they're scored again
+func (s *ConfigService) SetRelevanceDictionary(dictionary relevance.Dictionary) error {
+	data, err := json.Marshal(dictionary)
+	if err != nil {
+		return err
+	}
+
+	value := string(data)
+	return s.Set(RelevanceDictionary, &value)
+}
+
+func (s *ConfigService) RelevanceScorer() (*relevance.Scorer, error) {
+	dictionary, err := s.GetRelevanceDictionary()
+	if err != nil {
+		return nil, err
+	}
+	return relevance.NewScorer(dictionary), nil
+}
+
+func scoreRelevance(scorer *relevance.Scorer, c *model.Content, skateSource bool) {
+	score := scorer.Score(relevance.Input{
+		Title:       c.Title,
+		Summary:     c.Summary,
+		Keywords:    c.Tags,
+		SkateSource: skateSource,
+	})
+	c.Relevance = &score
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/database"
+	"gorm.io/gorm"
+)
+
+// Text search config of each lang, the other langs use simple
+var searchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// Every config of searchConfigs and simple, sorted
+func searchConfigNames() []string {
+	names := []string{"simple"}
+	for _, name := range searchConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Search columns of the contents, kept up to date by triggers. The text search
+// config follows the lang of the content, or the one of its source, so words
+// are stemmed the same way in the contents and in the queries.
+const searchMigration = `
+CREATE OR REPLACE FUNCTION search_config(iso text) RETURNS regconfig AS $$
+	SELECT (CASE iso
+%s
+		ELSE 'simple'
+	END)::regconfig
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS search_config regconfig NOT NULL DEFAULT 'simple';
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_contents_search_vector ON contents USING GIN (search_vector);
+
+CREATE OR REPLACE FUNCTION contents_search_vector() RETURNS trigger AS $$
+DECLARE
+	source_title text;
+	source_lang text;
+BEGIN
+	SELECT title, lang_iso_code INTO source_title, source_lang FROM sources WHERE id = NEW.source_id;
+
+	NEW.search_config := search_config(COALESCE(NULLIF(NEW.lang_iso_code, ''), source_lang));
+	NEW.search_vector :=
+		setweight(to_tsvector(NEW.search_config, COALESCE(NEW.title, '')), 'A') ||
+		setweight(to_tsvector(NEW.search_config, COALESCE(source_title, '')), 'B') ||
+		setweight(to_tsvector(NEW.search_config, COALESCE(NEW.summary, '')), 'C') ||
+		setweight(to_tsvector(NEW.search_config, left(COALESCE(NEW.content, ''), 100000)), 'D');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS contents_search_vector ON contents;
+CREATE TRIGGER contents_search_vector
+	BEFORE INSERT OR UPDATE OF title, summary, content, lang_iso_code, source_id ON contents
+	FOR EACH ROW EXECUTE FUNCTION contents_search_vector();
+
+CREATE OR REPLACE FUNCTION sources_search_vector() RETURNS trigger AS $$
+BEGIN
+	UPDATE contents SET title = title WHERE source_id = NEW.id;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS sources_search_vector ON sources;
+CREATE TRIGGER sources_search_vector
+	AFTER UPDATE OF title, lang_iso_code ON sources
+	FOR EACH ROW
+	WHEN (OLD.title IS DISTINCT FROM NEW.title OR OLD.lang_iso_code IS DISTINCT FROM NEW.lang_iso_code)
+	EXECUTE FUNCTION sources_search_vector();
+`
+
+// Index the contents stored before the search columns were added, only once
+const searchBackfill = `UPDATE contents SET title = title WHERE search_vector IS NULL;`
+
+func searchMigrationSQL() string {
+	isoCodes := make([]string, 0, len(searchConfigs))
+	for isoCode := range searchConfigs {
+		isoCodes = append(isoCodes, isoCode)
+	}
+	sort.Strings(isoCodes)
+
+	cases := make([]string, len(isoCodes))
+	for i, isoCode := range isoCodes {
+		cases[i] = fmt.Sprintf("\t\tWHEN '%s' THEN '%s'", isoCode, searchConfigs[isoCode])
+	}
+	return fmt.Sprintf(searchMigration, strings.Join(cases, "\n"))
+}
+
+// Create the search columns and their triggers. The first time, index the
+// stored contents. Runs after the auto migration
+func MigrateSearch(db *gorm.DB) error {
+	indexed := db.Migrator().HasColumn(&model.Content{}, "search_vector")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(searchMigrationSQL()).Error; err != nil {
+			return err
+		}
+		if indexed {
+			return nil
+		}
+		return tx.Exec(searchBackfill).Error
+	})
+}
+
+// Contents matching the @query parameter. Each config is tested on its own with
+// a constant tsquery, a config taken from the rows would skip the GIN index
+func searchCondition() string {
+	names := searchConfigNames()
+	conditions := make([]string, len(names))
+	for i, name := range names {
+		conditions[i] = fmt.Sprintf("(contents.search_config = '%s' AND contents.search_vector @@ websearch_to_tsquery('%s', @query))", name, name)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// Matches are delimited by private use characters in the headlines of the
+// plain text, turned into <mark> tags once the text is escaped
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+
+	titleHeadline   = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	snippetHeadline = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" … \""
+)
+
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// HTML of a headline, the text escaped and the matches in <mark> tags
+func highlight(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
+}
+
+// A content matching a search, with the matching parts highlighted
+type SearchResult struct {
+	Content model.Content `json:"content"`
+	Rank    float64       `json:"rank"`
+	Title   string        `json:"title"`   // HTML of the title, with the matches in <mark> tags
+	Snippet string        `json:"snippet"` // HTML of parts of the summary, or of the body without summary, with the matches in <mark> tags
+} // @name SearchResult
+
+type searchHit struct {
+	ID      string
+	Rank    float64
+	Title   string
+	Snippet string
+}
+
+// Search the contents by their title, summary, body and source title.
+// The query follows the web search syntax: "quoted phrases", or and -excluded words.
+// Without sort, the best matches come first
+func (s *ContentService) Search(query string, filters ContentFilters, sort string, page int, projection Projection) (*database.Pagination, error) {
+	pagination := &database.Pagination{
+		PerPage: 50,
+		Page:    page,
+	}
+
+	query = strings.TrimSpace(query)
+	tsquery := "websearch_to_tsquery(contents.search_config, @query)"
+	params := map[string]interface{}{
+		"query":           query,
+		"titleHeadline":   titleHeadline,
+		"snippetHeadline": snippetHeadline,
+	}
+
+	tx := s.db.Model(&model.Content{}).
+		Select("contents.id, "+
+			"ts_rank_cd(contents.search_vector, "+tsquery+") AS rank, "+
+			"ts_headline(contents.search_config, contents.title, "+tsquery+", @titleHeadline) AS title, "+
+			"ts_headline(contents.search_config, COALESCE(NULLIF(contents.summary, ''), contents.content), "+tsquery+", @snippetHeadline) AS snippet", params).
+		Where("contents.published_at IS NOT NULL").
+		Where(searchCondition(), params).
+		Session(&gorm.Session{})
+
+	tx = filterContents(tx, filters)
+	if sort == "" {
+		tx = tx.Order("rank desc, contents.published_at desc")
+	} else {
+		tx = sortContents(tx, sort)
+	}
+
+	var hits []searchHit
+	if err := tx.Scopes(pagination.Scope()).Find(&hits).Error; err != nil {
+		return pagination, err
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var contents []model.Content
+	if err := projection.apply(s.db).
+		Where("contents.id IN ?", ids).
+		Find(&contents).Error; err != nil {
+		return pagination, err
+	}
+
+	byID := make(map[string]model.Content, len(contents))
+	for _, content := range contents {
+		byID[content.ID] = content
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		if content, ok := byID[hit.ID]; ok {
+			results = append(results, SearchResult{
+				Content: content,
+				Rank:    hit.Rank,
+				Title:   highlight(hit.Title),
+				Snippet: highlight(hit.Snippet),
+			})
+		}
+	}
+	pagination.Items = results
+
+	return pagination, nil
+}
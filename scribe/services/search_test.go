@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchMigrationSQL(t *testing.T) {
+	sql := searchMigrationSQL()
+	require.Contains(t, sql, "\t\tWHEN 'da' THEN 'danish'\n\t\tWHEN 'de' THEN 'german'\n")
+	require.Contains(t, sql, "WHEN 'tr' THEN 'turkish'\n\t\tELSE 'simple'")
+	require.NotContains(t, sql, searchBackfill)
+}
+
+func TestSearchCondition(t *testing.T) {
+	condition := searchCondition()
+	require.Contains(t, condition, "(contents.search_config = 'english' AND contents.search_vector @@ websearch_to_tsquery('english', @query))")
+	require.Contains(t, condition, "(contents.search_config = 'simple' AND contents.search_vector @@ websearch_to_tsquery('simple', @query))")
+	// The config of the rows is never used to parse the query
+	require.NotContains(t, condition, "websearch_to_tsquery(contents.")
+}
+
+func TestHighlight(t *testing.T) {
+	headline := "<img src=x onerror=alert(1)> " + highlightStart + "Kickflip" + highlightStop + " & more"
+	require.Equal(t, "&lt;img src=x onerror=alert(1)&gt; <mark>Kickflip</mark> &amp; more", highlight(headline))
+}
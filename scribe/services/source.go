@@ -1,8 +1,9 @@
 package services
 
 import (
-	"errors"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/skatekrak/scribe/fetchers"
 	"github.com/skatekrak/scribe/model"
@@ -20,7 +21,7 @@ func NewSourceService(db *gorm.DB) *SourceService {
 
 func (s *SourceService) FindAll(types []string) ([]*model.Source, error) {
 	var sources []*model.Source
-	query := s.db.Joins("Lang").Order("\"order\" asc").Session(&gorm.Session{})
+	query := s.db.Joins("Lang").Preload("IconImage").Order("\"order\" asc").Session(&gorm.Session{})
 
 	if len(types) > 0 {
 		query = query.Where("sources.source_type IN ?", types)
@@ -30,6 +31,26 @@ func (s *SourceService) FindAll(types []string) ([]*model.Source, error) {
 	return sources, err
 }
 
+// Sources with the IDs, in the order of the IDs, and the IDs not found
+func (s *SourceService) FindByIDs(ids []string) ([]model.Source, []string, error) {
+	numeric := []uint64{}
+	for _, id := range ids {
+		if n, err := strconv.ParseUint(id, 10, 32); err == nil {
+			numeric = append(numeric, n)
+		}
+	}
+
+	sources := []model.Source{}
+	if len(numeric) > 0 {
+		if err := s.db.Joins("Lang").Preload("IconImage").Where("sources.id IN ?", numeric).Find(&sources).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sources, missing := inRequestedOrder(ids, sources, func(s model.Source) string { return strconv.FormatUint(uint64(s.ID), 10) })
+	return sources, missing, nil
+}
+
 func (s *SourceService) Get(id string) (model.Source, error) {
 	var source model.Source
 	err := s.db.Where("id = ?", id).First(&source).Error
@@ -56,19 +77,102 @@ func (s *SourceService) GetNextOrder() (int, error) {
 }
 
 func (s *SourceService) Create(source *model.Source) error {
-	return s.db.Create(&source).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		created, err := restoreDeleted(tx, []*model.Source{source})
+		if err != nil {
+			return err
+		}
+		if len(created) > 0 {
+			if err := tx.Create(&source).Error; err != nil {
+				return err
+			}
+		}
+		return enqueueSourceWebhooks(tx, EventSourceCreated, []*model.Source{source})
+	})
 }
 
 func (s *SourceService) Update(source *model.Source) error {
-	return s.db.Save(&source).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&source).Error; err != nil {
+			return err
+		}
+		return enqueueSourceWebhooks(tx, EventSourceUpdated, []*model.Source{source})
+	})
 }
 
 func (s *SourceService) Delete(source *model.Source) error {
-	return s.db.Unscoped().Select("Contents").Delete(&source).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Soft deleted, and marked as updated for the sync
+		now := time.Now()
+		if err := tx.Model(&model.Content{}).Where("source_id = ?", source.ID).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(source).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return enqueueSourceWebhooks(tx, EventSourceDeleted, []*model.Source{source})
+	})
 }
 
 func (s *SourceService) AddMany(sources []*model.Source) error {
-	return s.db.CreateInBatches(sources, len(sources)).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		created, err := restoreDeleted(tx, sources)
+		if err != nil {
+			return err
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(created, len(created)).Error; err != nil {
+				return err
+			}
+		}
+		return enqueueSourceWebhooks(tx, EventSourceCreated, sources)
+	})
+}
+
+// Sources deleted before are brought back with the contents deleted along with
+// them, their source ID can't be inserted again. Returns the sources still to create
+func restoreDeleted(tx *gorm.DB, sources []*model.Source) ([]*model.Source, error) {
+	sourceIDs := make([]string, len(sources))
+	for i, source := range sources {
+		sourceIDs[i] = source.SourceID
+	}
+
+	var deleted []model.Source
+	if err := tx.Unscoped().Where("source_id IN ? AND deleted_at IS NOT NULL", sourceIDs).Find(&deleted).Error; err != nil {
+		return nil, err
+	}
+	if len(deleted) == 0 {
+		return sources, nil
+	}
+
+	bySourceID := make(map[string]model.Source, len(deleted))
+	for _, source := range deleted {
+		bySourceID[source.SourceID] = source
+	}
+
+	created := []*model.Source{}
+	for _, source := range sources {
+		stored, ok := bySourceID[source.SourceID]
+		if !ok {
+			created = append(created, source)
+			continue
+		}
+
+		source.ID = stored.ID
+		source.CreatedAt = stored.CreatedAt
+		source.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Save(source).Error; err != nil {
+			return nil, err
+		}
+
+		if err := tx.Unscoped().Model(&model.Content{}).
+			Where("source_id = ? AND deleted_at = ?", stored.ID, stored.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return created, nil
 }
 
 func (s *SourceService) UpdateOrder(updates map[int]map[string]interface{}) ([]model.Source, error) {
@@ -83,44 +187,154 @@ func (s *SourceService) UpdateOrder(updates map[int]map[string]interface{}) ([]m
 			sources = append(sources, s...)
 		}
 
-		return nil
+		updated := make([]*model.Source, len(sources))
+		for i := range sources {
+			updated[i] = &sources[i]
+		}
+		return enqueueSourceWebhooks(tx, EventSourceUpdated, updated)
 	})
 
 	return sources, err
 }
 
 func (s *SourceService) AddManyIfNotExist(data []fetchers.ChannelFetchData, sourceType string, nextOrder int) ([]*model.Source, error) {
+	sources, err := s.NewSources(data, sourceType, nextOrder)
+	if err != nil {
+		return sources, err
+	}
+
+	if err := s.AddMany(sources); err != nil {
+		return sources, err
+	}
+
+	return sources, nil
+}
+
+// Map the fetched channels that are not already in the database, without saving them
+func (s *SourceService) NewSources(data []fetchers.ChannelFetchData, sourceType string, nextOrder int) ([]*model.Source, error) {
 	sources := []*model.Source{}
 	index := 0
 
+	stored, err := s.FindBySourceIDs(channelSourceIDs(data))
+	if err != nil {
+		return sources, err
+	}
+
 	for _, source := range data {
-		if _, err := s.GetBySourceID(source.SourceID); err != nil {
-			// Only attempt to create source that are not already here
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				sources = append(sources, &model.Source{
-					Order:       nextOrder + index,
-					SourceType:  sourceType,
-					SourceID:    source.SourceID,
-					Title:       source.Title,
-					Description: source.Description,
-					ShortTitle:  source.Title,
-					CoverURL:    source.CoverURL,
-					IconURL:     source.IconURL,
-					WebsiteURL:  source.WebsiteURL,
-					SkateSource: source.SkateSource,
-					PublishedAt: source.PublishedAt,
-					Lang: model.Lang{
-						IsoCode: source.Lang,
-					},
+		// Only attempt to create source that are not already here, once
+		if _, ok := stored[source.SourceID]; !ok {
+			stored[source.SourceID] = nil
+			sources = append(sources, &model.Source{
+				Order:       nextOrder + index,
+				SourceType:  sourceType,
+				SourceID:    source.SourceID,
+				Title:       source.Title,
+				Description: source.Description,
+				ShortTitle:  source.Title,
+				CoverURL:    source.CoverURL,
+				IconURL:     source.IconURL,
+				WebsiteURL:  source.WebsiteURL,
+				SkateSource: source.SkateSource,
+				PublishedAt: source.PublishedAt,
+				Lang: model.Lang{
+					IsoCode: source.Lang,
+				},
+			})
+			index++
+		}
+	}
+
+	return sources, nil
+}
+
+func channelSourceIDs(data []fetchers.ChannelFetchData) []string {
+	sourceIDs := make([]string, len(data))
+	for i, channel := range data {
+		sourceIDs[i] = channel.SourceID
+	}
+	return sourceIDs
+}
+
+// Stored sources with the given source IDs, keyed by source ID
+func (s *SourceService) FindBySourceIDs(sourceIDs []string) (map[string]*model.Source, error) {
+	stored := make(map[string]*model.Source, len(sourceIDs))
+	if len(sourceIDs) <= 0 {
+		return stored, nil
+	}
+
+	var sources []*model.Source
+	if err := s.db.Where("source_id IN ?", sourceIDs).Find(&sources).Error; err != nil {
+		return stored, err
+	}
+
+	for _, source := range sources {
+		stored[source.SourceID] = source
+	}
+
+	return stored, nil
+}
+
+// Stored sources with unlocked fields that are empty and given by their channel
+// data, with the changes already applied
+func (s *SourceService) ChannelUpdates(data []fetchers.ChannelFetchData) ([]*model.Source, []SourceChange, error) {
+	stored, err := s.FindBySourceIDs(channelSourceIDs(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources := []*model.Source{}
+	changes := []SourceChange{}
+
+	for _, source := range stored {
+		for _, channel := range data {
+			if channel.SourceID != source.SourceID {
+				continue
+			}
+
+			if fields := updateFromChannel(source, channel); len(fields) > 0 {
+				sources = append(sources, source)
+				changes = append(changes, SourceChange{
+					ID:       source.ID,
+					SourceID: source.SourceID,
+					Fields:   fields,
 				})
-				index++
 			}
 		}
 	}
 
-	if err := s.AddMany(sources); err != nil {
-		return sources, err
+	return sources, changes, nil
+}
+
+func (s *SourceService) UpdateMany(sources []*model.Source) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			if err := tx.Save(source).Error; err != nil {
+				return err
+			}
+		}
+		return enqueueSourceWebhooks(tx, EventSourceUpdated, sources)
+	})
+}
+
+// Fill the empty fields of the source with the channel data. The filled ones may
+// have been edited, so they're kept
+func updateFromChannel(source *model.Source, channel fetchers.ChannelFetchData) map[string]FieldChange {
+	fields := map[string]FieldChange{}
+
+	set := func(name string, field *string, value string) {
+		if value == "" || *field != "" || source.LockedFields.Has(name) {
+			return
+		}
+		fields[name] = FieldChange{Old: *field, New: value}
+		*field = value
 	}
 
-	return sources, nil
+	set("shortTitle", &source.ShortTitle, channel.Title)
+	set("title", &source.Title, channel.Title)
+	set("description", &source.Description, channel.Description)
+	set("iconUrl", &source.IconURL, channel.IconURL)
+	set("coverUrl", &source.CoverURL, channel.CoverURL)
+	set("websiteUrl", &source.WebsiteURL, channel.WebsiteURL)
+
+	return fields
 }
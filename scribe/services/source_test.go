@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/skatekrak/scribe/fetchers"
+	"github.com/skatekrak/scribe/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateFromChannel(t *testing.T) {
+	channel := fetchers.ChannelFetchData{
+		Title:       "Feedly title",
+		Description: "Feedly description",
+		IconURL:     "https://feedly.com/icon.png",
+		WebsiteURL:  "https://example.com",
+	}
+
+	t.Run("edited fields are kept", func(t *testing.T) {
+		source := &model.Source{
+			Title:       "Edited title",
+			ShortTitle:  "Edited",
+			Description: "Edited description",
+			IconURL:     "https://example.com/icon.png",
+			WebsiteURL:  "https://example.com",
+		}
+
+		require.Empty(t, updateFromChannel(source, channel))
+		require.Equal(t, "Edited title", source.Title)
+		require.Equal(t, "Edited", source.ShortTitle)
+		require.Equal(t, "Edited description", source.Description)
+		require.Equal(t, "https://example.com/icon.png", source.IconURL)
+	})
+
+	t.Run("empty fields are filled", func(t *testing.T) {
+		source := &model.Source{Title: "Edited title"}
+
+		fields := updateFromChannel(source, channel)
+		require.Equal(t, map[string]FieldChange{
+			"shortTitle":  {Old: "", New: "Feedly title"},
+			"description": {Old: "", New: "Feedly description"},
+			"iconUrl":     {Old: "", New: "https://feedly.com/icon.png"},
+			"websiteUrl":  {Old: "", New: "https://example.com"},
+		}, fields)
+		require.Equal(t, "Edited title", source.Title)
+		require.Equal(t, "Feedly description", source.Description)
+	})
+
+	t.Run("locked fields stay empty", func(t *testing.T) {
+		source := &model.Source{Title: "Edited title", LockedFields: model.FieldList{"description"}}
+
+		fields := updateFromChannel(source, channel)
+		require.NotContains(t, fields, "description")
+		require.Empty(t, source.Description)
+	})
+}
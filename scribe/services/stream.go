@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/database"
+)
+
+// Postgres channel AddMany notifies the IDs of the inserted contents on
+const ContentsChannel = "scribe_contents"
+
+// Contents waiting to be sent to a subscriber. Subscribers falling behind
+// are closed, they resume from their last event
+const subscriptionBuffer = 64
+
+// Time before listening again after the connection was lost
+const listenRetryDelay = 5 * time.Second
+
+// Contents are ordered by the time they were inserted at, but are only seen
+// once their transaction commits: one can show up after a content inserted
+// later. Resuming goes back this long before the last event, so such contents
+// aren't missed, and the ones already received are sent again
+const streamResumeGrace = 2 * time.Minute
+
+// Position of a content in the stream
+func StreamCursor(c *model.Content) database.Cursor {
+	return database.Cursor{Time: c.CreatedAt, ID: c.ID}
+}
+
+// Position the stream resumes from after the given event
+func ResumeCursor(last database.Cursor) database.Cursor {
+	return database.Cursor{Time: last.Time.Add(-streamResumeGrace)}
+}
+
+// Contents loaded at once to be published
+const publishBatchSize = 100
+
+type Subscription struct {
+	Contents chan model.Content // Closed when the subscriber fell behind
+	filters  ContentFilters
+}
+
+// Where the stream loads the contents it publishes
+type streamContents interface {
+	FindByIDs(ids []string, projection Projection) ([]model.Content, []string, error)
+	FindCreatedAfter(filters ContentFilters, cursor database.Cursor, limit int) ([]model.Content, error)
+}
+
+// Broadcast the contents inserted by every instance, notified through Postgres
+type ContentStream struct {
+	cs  streamContents
+	dsn string
+
+	mutex         sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	last          *database.Cursor     // Of the latest content published, nil until one is
+	published     map[string]time.Time // Contents published lately, by their creation time
+}
+
+func NewContentStream(cs *ContentService, dsn string) *ContentStream {
+	return newContentStream(cs, dsn)
+}
+
+func newContentStream(cs streamContents, dsn string) *ContentStream {
+	return &ContentStream{
+		cs:            cs,
+		dsn:           dsn,
+		subscriptions: map[*Subscription]struct{}{},
+		published:     map[string]time.Time{},
+	}
+}
+
+// Listen to the inserted contents in the background, for as long as the app runs
+func (s *ContentStream) Start() {
+	ids := make(chan string, publishBatchSize*10)
+	go s.publishing(ids)
+
+	go func() {
+		for {
+			if err := s.listen(context.Background(), ids); err != nil {
+				log.Printf("Stopped listening to the new contents: %s", err)
+			}
+			time.Sleep(listenRetryDelay)
+		}
+	}()
+}
+
+func (s *ContentStream) listen(ctx context.Context, ids chan<- string) error {
+	conn, err := pgx.Connect(ctx, s.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ContentsChannel); err != nil {
+		return err
+	}
+
+	// The notifications sent while the connection was lost are gone
+	s.replay()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		ids <- notification.Payload
+	}
+}
+
+// Publish the notified contents, the ones notified while a batch was loaded together
+func (s *ContentStream) publishing(ids <-chan string) {
+	for id := range ids {
+		batch := []string{id}
+	pending:
+		for len(batch) < publishBatchSize {
+			select {
+			case id, ok := <-ids:
+				if !ok {
+					break pending
+				}
+				batch = append(batch, id)
+			default:
+				break pending
+			}
+		}
+
+		contents, missing, err := s.cs.FindByIDs(batch, Projection{})
+		if err != nil {
+			log.Printf("Couldn't load the new contents %v: %s", batch, err)
+			continue
+		}
+		if len(missing) > 0 {
+			log.Printf("Couldn't find the new contents %v", missing)
+		}
+		s.publish(contents)
+	}
+}
+
+// Publish the contents created since the latest one published, and the ones
+// created shortly before that could have been committed after it
+func (s *ContentStream) replay() {
+	s.mutex.Lock()
+	last := s.last
+	s.mutex.Unlock()
+	if last == nil {
+		return
+	}
+
+	cursor := ResumeCursor(*last)
+	for {
+		contents, err := s.cs.FindCreatedAfter(ContentFilters{}, cursor, publishBatchSize)
+		if err != nil {
+			log.Printf("Couldn't replay the new contents: %s", err)
+			return
+		}
+		s.publish(contents)
+
+		if len(contents) < publishBatchSize {
+			return
+		}
+		cursor = StreamCursor(&contents[len(contents)-1])
+	}
+}
+
+func (s *ContentStream) Subscribe(filters ContentFilters) *Subscription {
+	subscription := &Subscription{
+		Contents: make(chan model.Content, subscriptionBuffer),
+		filters:  filters,
+	}
+
+	s.mutex.Lock()
+	s.subscriptions[subscription] = struct{}{}
+	s.mutex.Unlock()
+
+	return subscription
+}
+
+func (s *ContentStream) Unsubscribe(subscription *Subscription) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.subscriptions[subscription]; ok {
+		delete(s.subscriptions, subscription)
+		close(subscription.Contents)
+	}
+}
+
+// Send each content not published yet to the subscriptions it matches
+func (s *ContentStream) publish(contents []model.Content) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range contents {
+		content := contents[i]
+		if _, ok := s.published[content.ID]; ok {
+			continue
+		}
+		s.published[content.ID] = content.CreatedAt
+
+		cursor := StreamCursor(&content)
+		if s.last == nil || cursor.Time.After(s.last.Time) || (cursor.Time.Equal(s.last.Time) && cursor.ID > s.last.ID) {
+			s.last = &cursor
+		}
+
+		for subscription := range s.subscriptions {
+			if !subscription.filters.Match(&content) {
+				continue
+			}
+
+			select {
+			case subscription.Contents <- content:
+			default:
+				delete(s.subscriptions, subscription)
+				close(subscription.Contents)
+			}
+		}
+	}
+
+	// Only the ones a replay can send again are remembered
+	if s.last != nil {
+		since := ResumeCursor(*s.last).Time
+		for id, createdAt := range s.published {
+			if createdAt.Before(since) {
+				delete(s.published, id)
+			}
+		}
+	}
+}
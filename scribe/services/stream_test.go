@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/database"
+	"github.com/stretchr/testify/require"
+)
+
+// Contents loaded by a stream, without database
+type stubStreamContents struct {
+	contents map[string]model.Content
+	created  []model.Content // Returned by FindCreatedAfter
+	batches  [][]string
+	cursors  []database.Cursor
+}
+
+func (s *stubStreamContents) FindByIDs(ids []string, projection Projection) ([]model.Content, []string, error) {
+	s.batches = append(s.batches, ids)
+	contents, missing := []model.Content{}, []string{}
+	for _, id := range ids {
+		if content, ok := s.contents[id]; ok {
+			contents = append(contents, content)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return contents, missing, nil
+}
+
+func (s *stubStreamContents) FindCreatedAfter(filters ContentFilters, cursor database.Cursor, limit int) ([]model.Content, error) {
+	s.cursors = append(s.cursors, cursor)
+	return s.created, nil
+}
+
+func received(subscription *Subscription) []string {
+	ids := []string{}
+	for {
+		select {
+		case content, ok := <-subscription.Contents:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, content.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestResumeCursor(t *testing.T) {
+	createdAt := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	last := StreamCursor(&model.Content{ID: "b", CreatedAt: createdAt})
+
+	// A content inserted just before the last event but committed after it is sent again
+	resume := ResumeCursor(last)
+	late := database.Cursor{Time: createdAt.Add(-time.Second), ID: "a"}
+	require.True(t, late.Time.After(resume.Time))
+	require.Equal(t, createdAt.Add(-streamResumeGrace), resume.Time)
+}
+
+func TestContentStream(t *testing.T) {
+	now := time.Now()
+	video := model.Content{ID: "video", CreatedAt: now, Source: model.Source{SourceType: "youtube"}}
+	article := model.Content{ID: "article", CreatedAt: now.Add(time.Second), Source: model.Source{SourceType: "rss"}}
+
+	t.Run("filters", func(t *testing.T) {
+		stream := newContentStream(&stubStreamContents{}, "")
+		all := stream.Subscribe(ContentFilters{})
+		videos := stream.Subscribe(ContentFilters{SourceTypes: []string{"youtube"}})
+
+		stream.publish([]model.Content{video, article})
+		require.Equal(t, []string{"video", "article"}, received(all))
+		require.Equal(t, []string{"video"}, received(videos))
+	})
+
+	t.Run("published once", func(t *testing.T) {
+		stream := newContentStream(&stubStreamContents{}, "")
+		subscription := stream.Subscribe(ContentFilters{})
+
+		stream.publish([]model.Content{video})
+		stream.publish([]model.Content{video, article})
+		require.Equal(t, []string{"video", "article"}, received(subscription))
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		stream := newContentStream(&stubStreamContents{}, "")
+		subscription := stream.Subscribe(ContentFilters{})
+
+		contents := make([]model.Content, subscriptionBuffer+1)
+		for i := range contents {
+			contents[i] = model.Content{ID: string(rune('a' + i)), CreatedAt: now}
+		}
+		stream.publish(contents)
+
+		require.Len(t, received(subscription), subscriptionBuffer)
+		_, open := <-subscription.Contents
+		require.False(t, open)
+		require.Empty(t, stream.subscriptions)
+	})
+
+	t.Run("unsubscribe twice", func(t *testing.T) {
+		stream := newContentStream(&stubStreamContents{}, "")
+		subscription := stream.Subscribe(ContentFilters{})
+
+		stream.Unsubscribe(subscription)
+		require.NotPanics(t, func() { stream.Unsubscribe(subscription) })
+		require.Empty(t, stream.subscriptions)
+	})
+
+	t.Run("notifications loaded in batches", func(t *testing.T) {
+		contents := &stubStreamContents{contents: map[string]model.Content{"video": video, "article": article}}
+		stream := newContentStream(contents, "")
+		subscription := stream.Subscribe(ContentFilters{})
+
+		ids := make(chan string, 3)
+		ids <- "video"
+		ids <- "article"
+		ids <- "gone"
+		close(ids)
+		stream.publishing(ids)
+
+		require.Equal(t, [][]string{{"video", "article", "gone"}}, contents.batches)
+		require.Equal(t, []string{"video", "article"}, received(subscription))
+	})
+
+	t.Run("replay after reconnecting", func(t *testing.T) {
+		missed := model.Content{ID: "missed", CreatedAt: now.Add(time.Minute)}
+		contents := &stubStreamContents{created: []model.Content{article, missed}}
+		stream := newContentStream(contents, "")
+
+		// Nothing published yet, nothing to replay
+		stream.replay()
+		require.Empty(t, contents.cursors)
+
+		subscription := stream.Subscribe(ContentFilters{})
+		stream.publish([]model.Content{video, article})
+		stream.replay()
+
+		require.Equal(t, []database.Cursor{ResumeCursor(StreamCursor(&article))}, contents.cursors)
+		require.Equal(t, []string{"video", "article", "missed"}, received(subscription))
+	})
+}
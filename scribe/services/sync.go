@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/database"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+const (
+	// Contents sent to a client syncing for the first time
+	syncContentsWindow = 30 * 24 * time.Hour
+	// Changes are only sent once they're this old, so the ones committed by a
+	// transaction started before the sync aren't skipped
+	syncLag = time.Minute
+)
+
+const syncMigration = `
+UPDATE contents SET updated_at = COALESCE(deleted_at, created_at) WHERE updated_at IS NULL;
+`
+
+// Give an update time to the contents stored before it was tracked. Runs after the auto migration
+func MigrateSync(db *gorm.DB) error {
+	return db.Exec(syncMigration).Error
+}
+
+// Position of a client in the changes of each resource
+type syncToken struct {
+	Langs    database.Cursor `json:"l"`
+	Sources  database.Cursor `json:"s"`
+	Contents database.Cursor `json:"c"`
+}
+
+func (t syncToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSyncToken(s string) (syncToken, error) {
+	var t syncToken
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, ErrInvalidSyncToken
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, ErrInvalidSyncToken
+	}
+	return t, nil
+}
+
+// Created, updated and deleted resources since a token. Deleted ones have their deletedAt set
+type SyncResult struct {
+	Langs    []model.Lang    `json:"langs"`
+	Sources  []model.Source  `json:"sources"`
+	Contents []model.Content `json:"contents"`
+	Token    string          `json:"token"` // To send on the next sync
+	More     bool            `json:"more"`  // Some changes didn't fit, sync again right away with the token
+} // @name SyncResult
+
+type SyncService struct {
+	db *gorm.DB
+}
+
+func NewSyncService(db *gorm.DB) *SyncService {
+	return &SyncService{db}
+}
+
+// Changes since the token, at most limit of each resource. Without token the
+// langs and sources are all sent, along with the recent contents
+func (s *SyncService) Changes(since string, limit int) (*SyncResult, error) {
+	token := syncToken{
+		Contents: database.Cursor{Time: time.Now().Add(-syncContentsWindow)},
+	}
+	if since != "" {
+		t, err := decodeSyncToken(since)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+
+	until := time.Now().Add(-syncLag)
+	result := &SyncResult{}
+	var more bool
+	var err error
+
+	result.Langs, more, err = changesSince(s.db,
+		database.Keyset{TimeColumn: "langs.updated_at", IDColumn: "langs.iso_code"},
+		&token.Langs, token.Langs.ID, until, limit,
+		func(l model.Lang) (time.Time, string) { return l.UpdatedAt, l.IsoCode },
+	)
+	if err != nil {
+		return nil, err
+	}
+	result.More = more
+
+	sourceID, _ := strconv.Atoi(token.Sources.ID)
+	result.Sources, more, err = changesSince(s.db.Preload("IconImage"),
+		database.Keyset{TimeColumn: "sources.updated_at", IDColumn: "sources.id"},
+		&token.Sources, sourceID, until, limit,
+		func(s model.Source) (time.Time, string) { return s.UpdatedAt, strconv.FormatUint(uint64(s.ID), 10) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	result.More = result.More || more
+
+	result.Contents, more, err = changesSince(s.db.Joins("Source").Preload("ThumbnailImage"),
+		database.Keyset{TimeColumn: "contents.updated_at", IDColumn: "contents.id"},
+		&token.Contents, token.Contents.ID, until, limit,
+		func(c model.Content) (time.Time, string) { return c.UpdatedAt, c.ID },
+	)
+	if err != nil {
+		return nil, err
+	}
+	result.More = result.More || more
+
+	result.Token = token.encode()
+	return result, nil
+}
+
+// Rows changed after the cursor, including the deleted ones, oldest change
+// first. The cursor is moved to the last one. after is the ID of the cursor,
+// typed like the ID column
+func changesSince[T any](tx *gorm.DB, keyset database.Keyset, cursor *database.Cursor, after interface{}, until time.Time, limit int, key func(T) (time.Time, string)) ([]T, bool, error) {
+	items := []T{}
+	err := tx.Unscoped().
+		Where(fmt.Sprintf("(%s, %s) > (?, ?)", keyset.TimeColumn, keyset.IDColumn), cursor.Time, after).
+		Where(fmt.Sprintf("%s <= ?", keyset.TimeColumn), until).
+		Order(fmt.Sprintf("%s asc, %s asc", keyset.TimeColumn, keyset.IDColumn)).
+		Limit(limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return items, false, err
+	}
+
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+
+	if len(items) > 0 {
+		cursor.Time, cursor.ID = key(items[len(items)-1])
+	}
+	return items, more, nil
+}
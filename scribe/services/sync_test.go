@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skatekrak/utils/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncToken(t *testing.T) {
+	token := syncToken{
+		Langs:    database.Cursor{Time: time.Date(2022, 7, 14, 18, 30, 0, 0, time.UTC), ID: "en"},
+		Sources:  database.Cursor{Time: time.Date(2022, 7, 15, 9, 0, 0, 0, time.UTC), ID: "12"},
+		Contents: database.Cursor{Time: time.Date(2022, 7, 16, 12, 0, 0, 0, time.UTC), ID: "f3b2c1"},
+	}
+
+	decoded, err := decodeSyncToken(token.encode())
+	require.NoError(t, err)
+	require.Equal(t, token, decoded)
+
+	_, err = decodeSyncToken("not a token")
+	require.ErrorIs(t, err, ErrInvalidSyncToken)
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/skatekrak/utils/database"
+	"gorm.io/gorm"
+)
+
+// Events sent to the webhooks
+const (
+	EventContentCreated = "content.created"
+	EventSourceCreated  = "source.created"
+	EventSourceUpdated  = "source.updated"
+	EventSourceDeleted  = "source.deleted"
+)
+
+const (
+	// Attempts before a delivery is given up
+	maxDeliveryAttempts = 8
+	// Delay before the first retry, doubled after each failure
+	deliveryBackoff    = 30 * time.Second
+	maxDeliveryBackoff = 6 * time.Hour
+	deliveryTimeout    = 10 * time.Second
+	// Added to the time sending the claimed deliveries can take, for the saves
+	deliveryLeaseMargin = time.Minute
+)
+
+// Body of a delivery
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+} // @name WebhookPayload
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{db, &http.Client{Timeout: deliveryTimeout}}
+}
+
+func (s *WebhookService) FindAll() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := s.db.Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (s *WebhookService) Get(id string) (model.Webhook, error) {
+	var webhook model.Webhook
+	err := s.db.Where("id = ?", id).First(&webhook).Error
+	return webhook, err
+}
+
+// Create the webhook, with a random secret when it has none
+func (s *WebhookService) Create(webhook *model.Webhook) error {
+	if webhook.Secret == "" {
+		secret, err := NewWebhookSecret()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+
+	return s.db.Create(webhook).Error
+}
+
+func (s *WebhookService) Update(webhook *model.Webhook) error {
+	return s.db.Save(webhook).Error
+}
+
+// Delete the webhook along with its pending deliveries
+func (s *WebhookService) Delete(webhook *model.Webhook) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ? AND status = ?", webhook.ID, model.DeliveryPending).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+// Delivery log of the webhook, latest first
+func (s *WebhookService) FindDeliveries(webhookID uint, status string, page int) (*database.Pagination, error) {
+	pagination := &database.Pagination{
+		PerPage: 50,
+		Page:    page,
+		Items:   []model.WebhookDelivery{},
+	}
+
+	tx := s.db.Model(pagination.Items).Where("webhook_id = ?", webhookID).Session(&gorm.Session{})
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+
+	err := tx.Order("id desc").Scopes(pagination.Scope()).Find(&pagination.Items).Error
+	return pagination, err
+}
+
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Whether the webhook wants the event about the content, or the source when
+// content is nil. Only the filters on the sources apply to the source events
+func webhookMatches(webhook *model.Webhook, event string, source *model.Source, content *model.Content) bool {
+	if !webhook.Enabled || !webhook.Events.Has(event) {
+		return false
+	}
+
+	f := webhook.Filters
+	filters := ContentFilters{
+		SourceTypes:  f.SourceTypes,
+		Sources:      f.Sources,
+		Types:        f.Types,
+		Langs:        f.Langs,
+		SkateOnly:    f.SkateOnly,
+		MinRelevance: f.MinRelevance,
+	}
+
+	if content != nil {
+		return filters.Match(content)
+	}
+
+	filters.Types = nil
+	filters.MinRelevance = nil
+	return filters.Match(&model.Content{SourceID: source.ID, Source: *source})
+}
+
+// Queue the events of the sources to the webhooks wanting them, in the transaction changing them
+func enqueueSourceWebhooks(tx *gorm.DB, event string, sources []*model.Source) error {
+	webhooks, err := subscribedWebhooks(tx, event)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	deliveries := []model.WebhookDelivery{}
+	for _, source := range sources {
+		for i := range webhooks {
+			if !webhookMatches(&webhooks[i], event, source, nil) {
+				continue
+			}
+
+			delivery, err := newDelivery(webhooks[i].ID, event, source)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return createDeliveries(tx, deliveries)
+}
+
+// Queue the inserted contents to the webhooks wanting them, in the transaction inserting them
+func enqueueContentWebhooks(tx *gorm.DB, contents []*model.Content) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	webhooks, err := subscribedWebhooks(tx, EventContentCreated)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	// The filters and the payload need the sources of the contents
+	sourceIDs := []uint{}
+	for _, content := range contents {
+		sourceIDs = append(sourceIDs, content.SourceID)
+	}
+	var sources []model.Source
+	if err := tx.Where("id IN ?", sourceIDs).Find(&sources).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]model.Source, len(sources))
+	for _, source := range sources {
+		byID[source.ID] = source
+	}
+
+	deliveries := []model.WebhookDelivery{}
+	for _, c := range contents {
+		content := *c
+		content.Source = byID[content.SourceID]
+
+		for i := range webhooks {
+			if !webhookMatches(&webhooks[i], EventContentCreated, nil, &content) {
+				continue
+			}
+
+			delivery, err := newDelivery(webhooks[i].ID, EventContentCreated, content)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return createDeliveries(tx, deliveries)
+}
+
+func subscribedWebhooks(tx *gorm.DB, event string) ([]model.Webhook, error) {
+	events, err := json.Marshal([]string{event})
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []model.Webhook
+	err = tx.Where("enabled AND events @> ?", string(events)).Find(&webhooks).Error
+	return webhooks, err
+}
+
+func newDelivery(webhookID uint, event string, data interface{}) (model.WebhookDelivery, error) {
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{Event: event, CreatedAt: now, Data: data})
+
+	return model.WebhookDelivery{
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now,
+	}, err
+}
+
+func createDeliveries(tx *gorm.DB, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(deliveries, 100).Error
+}
+
+// Signature of the body sent at the timestamp, hex encoded
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Delay before retrying a delivery that failed the given number of times
+func deliveryRetryDelay(attempts int) time.Duration {
+	delay := deliveryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDeliveryBackoff {
+			return maxDeliveryBackoff
+		}
+	}
+	return delay
+}
+
+// Time a dispatcher has to send the given number of deliveries it claimed,
+// before another one can claim them
+func deliveryLease(deliveries int) time.Duration {
+	return time.Duration(deliveries)*deliveryTimeout + deliveryLeaseMargin
+}
+
+// Send the due deliveries, returns how many were delivered
+func (s *WebhookService) DeliverPending(limit int) (int, error) {
+	// Claimed for the lease so the other instances skip them while they are sent
+	var deliveries []model.WebhookDelivery
+	err := s.db.Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		time.Now().Add(deliveryLease(limit)), model.DeliveryPending, time.Now(), limit,
+	).Scan(&deliveries).Error
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	webhookIDs := []uint{}
+	for _, delivery := range deliveries {
+		webhookIDs = append(webhookIDs, delivery.WebhookID)
+	}
+	var webhooks []model.Webhook
+	if err := s.db.Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+		return 0, err
+	}
+	byID := make(map[uint]*model.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := byID[delivery.WebhookID]
+
+		if i > 0 {
+			if err := s.renewLease(deliveries[i:]); err != nil {
+				return delivered, err
+			}
+		}
+
+		if ok && webhook.Enabled {
+			s.send(webhook, delivery)
+		} else {
+			delivery.Attempts++
+			delivery.LastStatusCode = 0
+			delivery.LastError = "webhook deleted or disabled"
+			delivery.Status = model.DeliveryFailed
+		}
+
+		if delivery.Status == model.DeliveryDelivered {
+			delivered++
+		}
+
+		if err := s.db.Model(delivery).Select("Status", "Attempts", "NextAttemptAt", "LastStatusCode", "LastError", "DeliveredAt").Updates(delivery).Error; err != nil {
+			log.Printf("Couldn't save the webhook delivery %d: %s", delivery.ID, err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// Keep the deliveries left to send claimed for as long as sending them can take
+func (s *WebhookService) renewLease(deliveries []model.WebhookDelivery) error {
+	ids := make([]uint, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+
+	return s.db.Model(&model.WebhookDelivery{}).
+		Where("id IN ? AND status = ?", ids, model.DeliveryPending).
+		UpdateColumn("next_attempt_at", time.Now().Add(deliveryLease(len(deliveries)))).Error
+}
+
+// Post the delivery to the webhook and record the attempt on it
+func (s *WebhookService) send(webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := s.post(webhook, delivery)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= maxDeliveryAttempts {
+		delivery.Status = model.DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(deliveryRetryDelay(delivery.Attempts))
+	}
+}
+
+func (s *WebhookService) post(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Scribe-Webhook")
+	req.Header.Set("X-Scribe-Event", delivery.Event)
+	req.Header.Set("X-Scribe-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Scribe-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Scribe-Signature", "sha256="+SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
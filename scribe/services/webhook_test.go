@@ -0,0 +1,59 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/skatekrak/scribe/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"content.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1657823400." + string(body)))
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), SignWebhook("secret", 1657823400, body))
+
+	require.NotEqual(t, SignWebhook("secret", 1657823400, body), SignWebhook("secret", 1657823401, body))
+	require.NotEqual(t, SignWebhook("secret", 1657823400, body), SignWebhook("other", 1657823400, body))
+}
+
+func TestDeliveryRetryDelay(t *testing.T) {
+	require.Equal(t, 30*time.Second, deliveryRetryDelay(1))
+	require.Equal(t, time.Minute, deliveryRetryDelay(2))
+	require.Equal(t, 4*time.Minute, deliveryRetryDelay(4))
+	require.Equal(t, maxDeliveryBackoff, deliveryRetryDelay(20))
+}
+
+func TestDeliveryLease(t *testing.T) {
+	// Outlasts the claimed deliveries all timing out
+	require.Greater(t, deliveryLease(50), 50*deliveryTimeout)
+	require.Greater(t, deliveryLease(1), deliveryTimeout)
+}
+
+func TestWebhookMatches(t *testing.T) {
+	source := &model.Source{SourceType: "youtube", LangIsoCode: "en", SkateSource: true}
+	source.ID = 3
+	content := &model.Content{SourceID: 3, Source: *source, Type: "video"}
+
+	webhook := &model.Webhook{
+		Events:  model.FieldList{EventContentCreated, EventSourceUpdated},
+		Filters: model.WebhookFilters{Sources: []int{3}, Types: []string{"video"}},
+		Enabled: true,
+	}
+
+	require.True(t, webhookMatches(webhook, EventContentCreated, nil, content))
+	require.True(t, webhookMatches(webhook, EventSourceUpdated, source, nil), "content filters don't apply to sources")
+	require.False(t, webhookMatches(webhook, EventSourceCreated, source, nil))
+
+	article := *content
+	article.Type = "article"
+	require.False(t, webhookMatches(webhook, EventContentCreated, nil, &article))
+
+	webhook.Enabled = false
+	require.False(t, webhookMatches(webhook, EventContentCreated, nil, content))
+}
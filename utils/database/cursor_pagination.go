@@ -0,0 +1,142 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Position of an item in a list sorted by a time then an ID, newest first.
+// Sent to the clients as an opaque string
+type Cursor struct {
+	Time     time.Time `json:"t"`
+	ID       string    `json:"id"`
+	Backward bool      `json:"b,omitempty"` // Points to the items before this one instead of after
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.Time.IsZero() || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Columns a list is sorted by, newest first
+type Keyset struct {
+	TimeColumn string
+	IDColumn   string
+}
+
+type CursorPagination struct {
+	Limit        int         `json:"limit"`
+	Next         *string     `json:"next"` // Null on the last page
+	Prev         *string     `json:"prev"` // Null on the first page
+	TotalResults *int64      `json:"totalResults,omitempty"`
+	Items        interface{} `json:"items"`
+} // @Name CursorPagination
+
+func (p *CursorPagination) GetLimit() int {
+	if p.Limit <= 0 {
+		return 20
+	}
+	return p.Limit
+}
+
+// Fetch the page of items following the cursor, or the first one without cursor.
+// key gives the time and the ID of an item. Items added in the meantime don't
+// shift the pages, unlike with Pagination.
+func FindWithCursor[T any](tx *gorm.DB, keyset Keyset, cursor string, p *CursorPagination, count bool, key func(T) (time.Time, string)) error {
+	limit := p.GetLimit()
+	p.Limit = limit
+
+	var from *Cursor
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		from = &c
+	}
+
+	if count {
+		var total int64
+		if err := tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+		p.TotalResults = &total
+	}
+
+	order := "desc"
+	if from != nil {
+		if from.Backward {
+			order = "asc"
+			tx = tx.Where(fmt.Sprintf("(%s, %s) > (?, ?)", keyset.TimeColumn, keyset.IDColumn), from.Time, from.ID)
+		} else {
+			tx = tx.Where(fmt.Sprintf("(%s, %s) < (?, ?)", keyset.TimeColumn, keyset.IDColumn), from.Time, from.ID)
+		}
+	}
+
+	items := []T{}
+	err := tx.
+		Order(fmt.Sprintf("%s %s, %s %s", keyset.TimeColumn, order, keyset.IDColumn, order)).
+		Limit(limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return err
+	}
+
+	setPage(p, items, from, key)
+	return nil
+}
+
+// Fill the pagination with the page of items, fetched from the cursor with
+// one more item than the limit to tell if there's more
+func setPage[T any](p *CursorPagination, items []T, from *Cursor, key func(T) (time.Time, string)) {
+	more := len(items) > p.Limit
+	if more {
+		items = items[:p.Limit]
+	}
+
+	backward := from != nil && from.Backward
+	if backward {
+		// Fetched from the cursor, put them back newest first
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	p.Items = items
+	p.Next, p.Prev = nil, nil
+	if len(items) == 0 {
+		return
+	}
+
+	// There's always something after a page reached backward, and before a page reached forward
+	if more || backward {
+		t, id := key(items[len(items)-1])
+		next := Cursor{Time: t, ID: id}.Encode()
+		p.Next = &next
+	}
+	if (more && backward) || (from != nil && !backward) {
+		t, id := key(items[0])
+		prev := Cursor{Time: t, ID: id, Backward: true}.Encode()
+		p.Prev = &prev
+	}
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		cursor := Cursor{
+			Time:     time.Date(2022, 7, 14, 18, 30, 12, 123456000, time.UTC),
+			ID:       "8f6c1a7e-4b7f-4a8e-9d36-2f1b7c0e5d11",
+			Backward: true,
+		}
+
+		decoded, err := DecodeCursor(cursor.Encode())
+		require.NoError(t, err)
+		require.True(t, cursor.Time.Equal(decoded.Time))
+		require.Equal(t, cursor.ID, decoded.ID)
+		require.True(t, decoded.Backward)
+	})
+
+	t.Run("url safe", func(t *testing.T) {
+		encoded := Cursor{Time: time.Now(), ID: "???>>>"}.Encode()
+		require.NotContains(t, encoded, "+")
+		require.NotContains(t, encoded, "/")
+		require.NotContains(t, encoded, "=")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{"not a cursor", "e30", Cursor{ID: "id"}.Encode(), Cursor{Time: time.Now()}.Encode()} {
+			_, err := DecodeCursor(s)
+			require.ErrorIs(t, err, ErrInvalidCursor, s)
+		}
+	})
+}
+
+func TestCursorPaginationLimit(t *testing.T) {
+	require.Equal(t, 20, (&CursorPagination{}).GetLimit())
+	require.Equal(t, 50, (&CursorPagination{Limit: 50}).GetLimit())
+}
+
+type item struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+func itemKey(i item) (time.Time, string) {
+	return i.CreatedAt, i.ID
+}
+
+// Page of the items from the cursor, like the query of FindWithCursor
+func fetchPage(t *testing.T, items []item, cursor *string, limit int) *CursorPagination {
+	p := &CursorPagination{Limit: limit}
+
+	var from *Cursor
+	page := []item{}
+	if cursor == nil {
+		page = append(page, items...)
+	} else {
+		c, err := DecodeCursor(*cursor)
+		require.NoError(t, err)
+		from = &c
+
+		for _, i := range items {
+			before := i.CreatedAt.Before(c.Time) || (i.CreatedAt.Equal(c.Time) && i.ID < c.ID)
+			after := i.CreatedAt.After(c.Time) || (i.CreatedAt.Equal(c.Time) && i.ID > c.ID)
+			if (c.Backward && after) || (!c.Backward && before) {
+				page = append(page, i)
+			}
+		}
+		if c.Backward {
+			for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+				page[i], page[j] = page[j], page[i]
+			}
+		}
+	}
+	if len(page) > limit+1 {
+		page = page[:limit+1]
+	}
+
+	setPage(p, page, from, itemKey)
+	return p
+}
+
+func TestSetPage(t *testing.T) {
+	now := time.Date(2022, 7, 14, 18, 30, 0, 0, time.UTC)
+	// Newest first, with ties on the time
+	items := []item{
+		{"e", now},
+		{"d", now.Add(-time.Minute)},
+		{"c", now.Add(-time.Minute)},
+		{"b", now.Add(-time.Minute)},
+		{"a", now.Add(-time.Hour)},
+	}
+	ids := func(p *CursorPagination) []string {
+		page := []string{}
+		for _, i := range p.Items.([]item) {
+			page = append(page, i.ID)
+		}
+		return page
+	}
+
+	first := fetchPage(t, items, nil, 2)
+	require.Equal(t, []string{"e", "d"}, ids(first))
+	require.Nil(t, first.Prev)
+	require.NotNil(t, first.Next)
+
+	second := fetchPage(t, items, first.Next, 2)
+	require.Equal(t, []string{"c", "b"}, ids(second))
+	require.NotNil(t, second.Prev)
+	require.NotNil(t, second.Next)
+
+	last := fetchPage(t, items, second.Next, 2)
+	require.Equal(t, []string{"a"}, ids(last))
+	require.NotNil(t, last.Prev)
+	require.Nil(t, last.Next)
+
+	t.Run("back to the first page", func(t *testing.T) {
+		back := fetchPage(t, items, last.Prev, 2)
+		require.Equal(t, []string{"c", "b"}, ids(back))
+		require.NotNil(t, back.Prev)
+		require.NotNil(t, back.Next)
+
+		back = fetchPage(t, items, back.Prev, 2)
+		require.Equal(t, []string{"e", "d"}, ids(back))
+		require.Nil(t, back.Prev)
+		require.NotNil(t, back.Next)
+	})
+
+	t.Run("exact fit", func(t *testing.T) {
+		page := fetchPage(t, items[:2], nil, 2)
+		require.Equal(t, []string{"e", "d"}, ids(page))
+		require.Nil(t, page.Prev)
+		require.Nil(t, page.Next)
+	})
+
+	t.Run("past the end", func(t *testing.T) {
+		cursor := Cursor{Time: items[4].CreatedAt, ID: items[4].ID}.Encode()
+		page := fetchPage(t, items, &cursor, 2)
+		require.Empty(t, ids(page))
+		require.Nil(t, page.Prev)
+		require.Nil(t, page.Next)
+	})
+}
+
+func TestFindWithCursorQuery(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DisableAutomaticPing: true,
+		DryRun:               true,
+	})
+	require.NoError(t, err)
+
+	sql := ""
+	err = db.Callback().Query().After("gorm:query").Register("test:last_query", func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	})
+	require.NoError(t, err)
+
+	keyset := Keyset{TimeColumn: "created_at", IDColumn: "id"}
+	at := time.Date(2022, 7, 14, 18, 30, 0, 0, time.UTC)
+
+	err = FindWithCursor(db.Model(&item{}), keyset, Cursor{Time: at, ID: "c"}.Encode(), &CursorPagination{Limit: 2}, false, itemKey)
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE (created_at, id) < ('2022-07-14 18:30:00', 'c') ORDER BY created_at desc, id desc LIMIT 3`)
+
+	err = FindWithCursor(db.Model(&item{}), keyset, Cursor{Time: at, ID: "c", Backward: true}.Encode(), &CursorPagination{Limit: 2}, false, itemKey)
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE (created_at, id) > ('2022-07-14 18:30:00', 'c') ORDER BY created_at asc, id asc LIMIT 3`)
+}